@@ -4,7 +4,9 @@ import (
 	"crypto/md5"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/simpleelegant/notes/diagram"
 )
@@ -29,6 +31,36 @@ func formValue(r *http.Request, key string) string {
 	return strings.TrimSpace(r.FormValue(key))
 }
 
+// pagination read offset and limit of a page, limit defaults to 20
+func pagination(r *http.Request) (offset, limit int, err error) {
+	const maxLimit = 100
+
+	offset, limit = 0, 20
+	if v := formValue(r, "offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			return
+		}
+	}
+	if v := formValue(r, "limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			return
+		}
+	}
+	if offset < 0 || limit < 1 || limit > maxLimit {
+		err = fmt.Errorf("offset must not be negative, limit must be in 1..%d",
+			maxLimit)
+	}
+	return
+}
+
+// formatTime format t in RFC 3339, or empty for zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
 func replyInfo(w http.ResponseWriter, info interface{}) {
 	const tmpl = `<!DOCTYPE html>
 <html>
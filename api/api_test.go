@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/simpleelegant/notes/resources"
+)
+
+// openTestDatabase open a notebook in memory
+func openTestDatabase(t *testing.T) {
+	err := resources.OpenDatabase(resources.MemoryBackend, t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// postForm a request posting form, with If-Match ifMatch unless it is empty
+func postForm(ifMatch string, form url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if ifMatch != "" {
+		r.Header.Set("If-Match", ifMatch)
+	}
+	return r
+}
+
+func TestPagination(t *testing.T) {
+	tests := []struct {
+		query         string
+		offset, limit int
+		ok            bool
+	}{
+		{"", 0, 20, true},
+		{"offset=40&limit=100", 40, 100, true},
+		{"limit=0", 0, 0, false},
+		{"limit=101", 0, 0, false},
+		{"offset=-1", 0, 0, false},
+		{"offset=x", 0, 0, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+		offset, limit, err := pagination(r)
+		if (err == nil) != tt.ok || tt.ok && (offset != tt.offset || limit != tt.limit) {
+			t.Errorf("pagination(%q) = %d, %d, %v", tt.query, offset, limit, err)
+		}
+	}
+}
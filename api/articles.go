@@ -3,22 +3,31 @@ package api
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/simpleelegant/notes/diagram"
 	"github.com/simpleelegant/notes/resources"
 )
 
-// CreateArticle create an article
+// CreateArticle create an article, or create it from a template
 func CreateArticle(r *http.Request) (int, interface{}) {
 	a := &resources.Article{
 		Parent:  formValue(r, "parent"),
 		Title:   formValue(r, "title"),
 		Content: formValue(r, "content"),
+		Tags:    resources.ParseTags(formValue(r, "tags")),
+	}
+	if err := resources.CheckUnlocked(session(r), a.Parent); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if template := formValue(r, "template"); template != "" {
+		return createFromTemplate(r, a, template)
 	}
 	if err := a.Create(); err != nil {
 		return http.StatusBadRequest, err
 	}
-	return http.StatusOK, map[string]string{"id": a.ID}
+	return http.StatusOK, Versioned{a.Version, map[string]string{"id": a.ID}}
 }
 
 // GetArticle get an article
@@ -27,10 +36,14 @@ func GetArticle(r *http.Request) (int, interface{}) {
 	if id == "" {
 		id = resources.RootArticleID
 	}
-	a, err := resources.GetArticle(id)
+	if searchID, ok := resources.SavedSearchID(id); ok {
+		return getSavedSearchNode(r, searchID)
+	}
+	a, err := getArticle(r, id)
 	if err != nil {
 		return http.StatusBadRequest, err
 	}
+	resources.RecordVisit(session(r), a.ID)
 	contentMD5, diagramMD5 := a.MD5()
 	var diagramSVG string
 	if a.Diagram != "" {
@@ -41,19 +54,32 @@ func GetArticle(r *http.Request) (int, interface{}) {
 			diagramSVG = string(out)
 		}
 	}
-	b := map[string]string{
+	b := map[string]interface{}{
 		"id":         a.ID,
 		"title":      a.Title,
 		"content":    a.Content,
-		"html":       string(a.ContentHTML()),
+		"html":       string(a.ContentHTML(session(r))),
 		"diagram":    a.Diagram,
 		"diagramSVG": diagramSVG,
 		"contentMD5": contentMD5,
 		"diagramMD5": diagramMD5,
+		"createdAt":  formatTime(a.CreatedAt),
+		"updatedAt":  formatTime(a.UpdatedAt),
+		"tags":       a.Tags,
+		"private":    a.Private,
+		"version":    a.Version,
+		"template":   a.Template,
+		"properties": a.Properties(),
 	}
 
 	// get sub-articles of a
-	subArticles, err := a.GetSubArticles()
+	order := formValue(r, "sort")
+	subArticles, err := navigationChildren(r, a, order)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	backlinks, err := a.Backlinks(session(r))
 	if err != nil {
 		return http.StatusBadRequest, err
 	}
@@ -64,41 +90,132 @@ func GetArticle(r *http.Request) (int, interface{}) {
 		subling []*resources.ArticleTitle
 	)
 	if a.Parent != "" {
-		p, err := resources.GetArticle(a.Parent)
-		if err != nil {
-			return http.StatusBadRequest, err
-		}
-		parent = &resources.ArticleTitle{ID: p.ID, Title: p.Title}
-		subling, err = p.GetSubArticles()
-		if err != nil {
+		if parent, subling, err = parentOf(r, a.Parent, order); err != nil {
 			return http.StatusBadRequest, err
 		}
 	}
 
-	return http.StatusOK, map[string]interface{}{
+	return http.StatusOK, Versioned{a.Version, map[string]interface{}{
 		"parent":            parent,
 		"childrenOfParent":  subling,
 		"current":           b,
 		"childrenOfCurrent": subArticles,
+		"backlinks":         backlinks,
+	}}
+}
+
+// getSavedSearchNode get a saved search as a virtual article, all articles
+// it matches are its sub-articles, like those of an article
+func getSavedSearchNode(r *http.Request, id string) (int, interface{}) {
+	s, err := resources.GetSavedSearch(id)
+	if err != nil {
+		return http.StatusBadRequest, err
 	}
+	result, err := s.Run(session(r))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	matched := make([]*resources.ArticleTitle, len(result.Hits))
+	for i, h := range result.Hits {
+		matched[i] = h.ArticleTitle
+	}
+	if err := resources.SortArticleTitles(matched,
+		formValue(r, "sort")); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	parent, subling, err := parentOf(r, s.Parent, formValue(r, "sort"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return http.StatusOK, Versioned{s.Version, map[string]interface{}{
+		"parent":           parent,
+		"childrenOfParent": subling,
+		"current": map[string]interface{}{
+			"id":          s.NodeID(),
+			"title":       s.Name,
+			"query":       s.Query,
+			"savedSearch": s.ID,
+			"version":     s.Version,
+			"total":       result.Total,
+			"createdAt":   formatTime(s.CreatedAt),
+		},
+		"childrenOfCurrent": matched,
+		"backlinks":         []*resources.ArticleTitle{},
+	}}
+}
+
+// parentOf get parent article and its sub-articles
+func parentOf(r *http.Request, id, order string) (*resources.ArticleTitle,
+	[]*resources.ArticleTitle, error) {
+	p, err := resources.GetArticle(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	subs, err := navigationChildren(r, p, order)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resources.ArticleTitle{ID: p.ID, Title: p.Title,
+		CreatedAt: p.CreatedAt, UpdatedAt: p.UpdatedAt}, subs, nil
+}
+
+// navigationChildren get sub-articles of a in order, followed by saved
+// searches shown under it
+func navigationChildren(r *http.Request, a *resources.Article, order string) (
+	[]*resources.ArticleTitle, error) {
+	subs, err := a.GetSubArticles(session(r))
+	if err != nil {
+		return nil, err
+	}
+	if err := resources.SortArticleTitles(subs, order); err != nil {
+		return nil, err
+	}
+	searches, err := resources.SavedSearchesUnder(session(r), a.ID)
+	if err != nil {
+		return nil, err
+	}
+	return append(subs, searches...), nil
 }
 
 // SearchArticles search articles
 func SearchArticles(r *http.Request) (int, interface{}) {
-	titleMatches, contentMatches, err := resources.SearchArticles(formValue(r, "pattern"))
+	offset, limit, err := pagination(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	result, err := resources.SearchArticles(session(r), r.FormValue("pattern"),
+		formValue(r, "sort"), offset, limit)
+	if e, ok := err.(*resources.QueryError); ok {
+		// in JSON, so that UI can point out where the error is
+		return http.StatusBadRequest, *e
+	}
 	if err != nil {
 		return http.StatusBadRequest, err
 	}
 
-	return http.StatusOK, map[string]interface{}{
-		"titleMatches":   titleMatches,
-		"contentMatches": contentMatches,
+	return http.StatusOK, result
+}
+
+// SwitchArticle find articles by fuzzy matching their titles,
+// for quickly jumping to an article
+func SwitchArticle(r *http.Request) (int, interface{}) {
+	_, limit, err := pagination(r)
+	if err != nil {
+		return http.StatusBadRequest, err
 	}
+	hits, err := resources.SwitchTo(session(r), r.FormValue("q"), limit)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return http.StatusOK, hits
 }
 
-// DeleteArticle delete an articles
+// DeleteArticle move an article with its sub-articles into trash
 func DeleteArticle(r *http.Request) (int, interface{}) {
-	a, err := resources.GetArticle(formValue(r, "id"))
+	a, err := getArticleIfMatch(r, formValue(r, "id"))
 	if err != nil {
 		return http.StatusBadRequest, err
 	}
@@ -108,31 +225,77 @@ func DeleteArticle(r *http.Request) (int, interface{}) {
 		return http.StatusBadRequest, errors.New("unable to delete root article")
 	}
 
-	// deny if has sub-articles
-	subs, err := a.GetSubArticles()
+	if err := a.MoveToTrash(); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return http.StatusOK, ""
+}
+
+// CopyArticle deep copy an article and its descendants under a parent. Like
+// CreateArticle it changes no version, so If-Match is not needed
+func CopyArticle(r *http.Request) (int, interface{}) {
+	a, err := getArticle(r, formValue(r, "id"))
 	if err != nil {
 		return http.StatusBadRequest, err
 	}
-	if len(subs) != 0 {
-		return http.StatusBadRequest, errors.New("must delete sub-articles")
+	if _, err := getArticle(r, formValue(r, "parent")); err != nil {
+		return http.StatusBadRequest, err
+	}
+	id, err := a.CopyTo(formValue(r, "parent"))
+	if err != nil {
+		return http.StatusBadRequest, err
 	}
+	return http.StatusOK, map[string]string{"id": id}
+}
 
-	if err := a.Delete(); err != nil {
+// MoveArticle change position of an article among its siblings,
+// by direction "up" or "down", or to a specified index. Order of siblings
+// is not part of any version, so If-Match is not needed
+func MoveArticle(r *http.Request) (int, interface{}) {
+	a, err := getArticle(r, formValue(r, "id"))
+	if err != nil {
 		return http.StatusBadRequest, err
 	}
 
-	return http.StatusOK, ""
+	switch formValue(r, "direction") {
+	case "up":
+		err = a.MoveBy(-1)
+	case "down":
+		err = a.MoveBy(1)
+	case "":
+		index, e := strconv.Atoi(formValue(r, "index"))
+		if e != nil {
+			return http.StatusBadRequest, e
+		}
+		err = a.MoveToIndex(index)
+	default:
+		return http.StatusBadRequest, errors.New("unknown direction")
+	}
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return http.StatusOK, "moved"
 }
 
-// UpdateArticle update an article
+// UpdateArticle update an article of the version named by If-Match. If it
+// was changed since, the changes are merged, and a conflict is returned
+// with the merged fields if both changed the same lines or fields.
 func UpdateArticle(r *http.Request) (int, interface{}) {
-	a, err := resources.GetArticle(formValue(r, "id"))
+	a, err := getArticle(r, formValue(r, "id"))
 	if err != nil {
 		return http.StatusBadRequest, err
 	}
-	contentMD5, diagramMD5 := a.MD5()
+	base := a
+	if err := checkVersion(r, a); err != nil {
+		if base = baseVersion(r, a); base == nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	m := newMerger(a)
 
-	var uParent, uTitle, uContent, uDiagram bool
+	var uParent, uTitle, uContent, uDiagram, uTags bool
 	{
 		if formValue(r, "uParent") == "true" {
 			uParent = true
@@ -146,45 +309,54 @@ func UpdateArticle(r *http.Request) (int, interface{}) {
 		if formValue(r, "uDiagram") == "true" {
 			uDiagram = true
 		}
+		if formValue(r, "uTags") == "true" {
+			uTags = true
+		}
 	}
 
 	if uParent {
-		parent := formValue(r, "parent")
-		if err := checkChangeParent(a, parent); err != nil {
+		parent := m.field("parent", base.Parent, a.Parent, formValue(r, "parent"))
+		if err := checkChangeParent(r, a, parent); err != nil {
 			return http.StatusBadRequest, err
 		}
 		a.Parent = parent
 	}
 	if uTitle {
-		a.Title = formValue(r, "title")
+		a.Title = m.field("title", base.Title, a.Title, formValue(r, "title"))
 	}
 	if uContent {
-		if formValue(r, "originalContentMD5") != contentMD5 {
-			return http.StatusBadRequest,
-				errors.New("content was changed by another operation")
-		}
-		a.Content = formValue(r, "content")
+		a.Content = m.text("content", base.Content, a.Content,
+			formValue(r, "content"))
 	}
 	if uDiagram {
-		if formValue(r, "originalDiagramMD5") != diagramMD5 {
-			return http.StatusBadRequest,
-				errors.New("diagram was changed by another operation.")
-		}
-		a.Diagram = formValue(r, "diagram")
+		a.Diagram = m.text("diagram", base.Diagram, a.Diagram,
+			formValue(r, "diagram"))
+	}
+
+	if uTags {
+		tags := resources.ParseTags(formValue(r, "tags"))
+		a.Tags = resources.ParseTags(m.field("tags", strings.Join(base.Tags, ","),
+			strings.Join(a.Tags, ","), strings.Join(tags, ",")))
 	}
 
-	if err := a.Update(uParent, uTitle, uContent, uDiagram); err != nil {
+	if len(m.conflicts) > 0 {
+		return http.StatusConflict, m.conflict()
+	}
+	if err := a.Update(uParent, uTitle, uContent, uDiagram, uTags); err != nil {
 		return http.StatusBadRequest, err
 	}
 
-	return http.StatusOK, "updated"
+	if base != a {
+		return http.StatusOK, Versioned{a.Version, "merged"}
+	}
+	return http.StatusOK, Versioned{a.Version, "updated"}
 }
 
-func checkChangeParent(a *resources.Article, parent string) error {
+func checkChangeParent(r *http.Request, a *resources.Article, parent string) error {
 	if a.ID == parent {
 		return errors.New("parent article cannot equal to current article")
 	}
-	if _, err := resources.GetArticle(parent); err != nil {
+	if _, err := getArticle(r, parent); err != nil {
 		return err
 	}
 	yes, err := a.IsAncestorOf(parent)
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path/filepath"
+
+	"github.com/simpleelegant/notes/resources"
+)
+
+// maxAttachmentSize limit of the size of an upload, in bytes
+const maxAttachmentSize = 32 << 20
+
+// inlineContentTypes content types of attachments shown inline, others are
+// downloaded, as they may run scripts in the origin of notes
+var inlineContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+	"image/bmp":  true,
+}
+
+// UploadAttachment attach an uploaded file to an article
+func UploadAttachment(r *http.Request) (int, interface{}) {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxAttachmentSize+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return http.StatusRequestEntityTooLarge, fmt.Errorf(
+				"attachment is larger than %d MB", maxAttachmentSize>>20)
+		}
+		return http.StatusBadRequest, err
+	}
+	if _, err := getArticleIfMatch(r, formValue(r, "article")); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	f, h, err := r.FormFile("file")
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	defer f.Close()
+	if h.Size > maxAttachmentSize {
+		return http.StatusRequestEntityTooLarge, fmt.Errorf(
+			"attachment is larger than %d MB", maxAttachmentSize>>20)
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	contentType := h.Header.Get("Content-Type")
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = mime.TypeByExtension(filepath.Ext(h.Filename))
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	t := &resources.Attachment{
+		Article:     formValue(r, "article"),
+		Name:        filepath.Base(h.Filename),
+		ContentType: contentType,
+		Data:        data,
+	}
+	if err := t.Create(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, map[string]interface{}{
+		"attachment": t,
+		"url":        resources.AttachmentURL(t.Article, t.ID),
+	}
+}
+
+// ListAttachments list attachments of an article
+func ListAttachments(r *http.Request) (int, interface{}) {
+	a, err := getArticle(r, formValue(r, "article"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	list, err := a.Attachments()
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, map[string]interface{}{"attachments": list}
+}
+
+// DeleteAttachment delete an attachment
+func DeleteAttachment(r *http.Request) (int, interface{}) {
+	t := &resources.Attachment{
+		Article: formValue(r, "article"),
+		ID:      formValue(r, "id"),
+	}
+	if _, err := getArticleIfMatch(r, t.Article); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := t.Delete(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, "deleted"
+}
+
+// DownloadAttachment serve content of an attachment, range requests
+// are supported
+func DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	if err := resources.CheckUnlocked(session(r), formValue(r, "article")); err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	t, err := resources.GetAttachment(formValue(r, "article"), formValue(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	disposition := "attachment"
+	if mediaType, _, _ := mime.ParseMediaType(t.ContentType); inlineContentTypes[mediaType] {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", t.ContentType)
+	w.Header().Set("Content-Disposition",
+		mime.FormatMediaType(disposition, map[string]string{"filename": t.Name}))
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, t.Name, t.CreatedAt, bytes.NewReader(t.Data))
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/simpleelegant/notes/resources"
+)
+
+func TestAttachments(t *testing.T) {
+	openTestDatabase(t)
+	upload := func(name, contentType string, data []byte) (int, interface{}) {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		w.WriteField("article", resources.RootArticleID)
+		h := make(map[string][]string)
+		h["Content-Disposition"] = []string{
+			`form-data; name="file"; filename="` + name + `"`}
+		h["Content-Type"] = []string{contentType}
+		part, _ := w.CreatePart(h)
+		part.Write(data)
+		w.Close()
+		r := httptest.NewRequest(http.MethodPost, "/", &buf)
+		r.Header.Set("Content-Type", w.FormDataContentType())
+		r.Header.Set("If-Match", "*")
+		return UploadAttachment(r)
+	}
+
+	if status, _ := upload("big.bin", "application/octet-stream",
+		make([]byte, maxAttachmentSize+2<<20)); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("upload of a large file = %d", status)
+	}
+
+	tests := []struct {
+		name, contentType string
+		disposition       string
+	}{
+		{"a.png", "image/png", "inline"},
+		{"a.html", "text/html", "attachment"},
+		{"a.svg", "image/svg+xml", "attachment"},
+	}
+	for _, tt := range tests {
+		status, body := upload(tt.name, tt.contentType, []byte("data"))
+		if status != http.StatusOK {
+			t.Fatalf("upload %s = %d, %v", tt.name, status, body)
+		}
+		at := body.(map[string]interface{})["attachment"].(*resources.Attachment)
+		w := httptest.NewRecorder()
+		DownloadAttachment(w, httptest.NewRequest(http.MethodGet, "/?"+url.Values{
+			"article": {resources.RootArticleID}, "id": {at.ID}}.Encode(), nil))
+		h := w.Result().Header
+		if !strings.HasPrefix(h.Get("Content-Disposition"), tt.disposition+";") ||
+			h.Get("Content-Security-Policy") != "sandbox" || w.Body.String() != "data" {
+			t.Errorf("download of %s: %v %q", tt.name, h, w.Body)
+		}
+	}
+}
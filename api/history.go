@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/simpleelegant/notes/diff"
+	"github.com/simpleelegant/notes/resources"
+)
+
+// ListRevisions list saved revisions of an article
+func ListRevisions(r *http.Request) (int, interface{}) {
+	a, err := getArticle(r, formValue(r, "id"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	revs, err := a.Revisions()
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, map[string]interface{}{"revisions": revs}
+}
+
+// GetRevision get a revision of an article
+func GetRevision(r *http.Request) (int, interface{}) {
+	a, err := getArticle(r, formValue(r, "id"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	number, err := strconv.ParseUint(formValue(r, "revision"), 10, 64)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	rev, err := a.GetRevision(number)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, map[string]interface{}{
+		"id":       rev.ID,
+		"revision": rev.Number,
+		"savedAt":  rev.SavedAt,
+		"parent":   rev.Parent,
+		"title":    rev.Title,
+		"content":  rev.Content,
+		"html":     string(rev.ContentHTML(session(r))),
+		"diagram":  rev.Diagram,
+	}
+}
+
+// DiffRevisions show line differences between two revisions of an article,
+// an empty or "current" revision refers to the current version
+func DiffRevisions(r *http.Request) (int, interface{}) {
+	a, err := getArticle(r, formValue(r, "id"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	from, err := revisionOrCurrent(a, formValue(r, "from"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	to, err := revisionOrCurrent(a, formValue(r, "to"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	return http.StatusOK, map[string]interface{}{
+		"title":   diff.Lines(from.Title, to.Title),
+		"content": diff.Lines(from.Content, to.Content),
+		"diagram": diff.Lines(from.Diagram, to.Diagram),
+	}
+}
+
+// RollbackArticle restore an article from one of its revisions
+func RollbackArticle(r *http.Request) (int, interface{}) {
+	a, err := getArticleIfMatch(r, formValue(r, "id"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	number, err := strconv.ParseUint(formValue(r, "revision"), 10, 64)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := a.Rollback(number); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, Versioned{a.Version, "rolled back"}
+}
+
+func revisionOrCurrent(a *resources.Article, revision string) (
+	*resources.Article, error) {
+	if revision == "" || revision == "current" {
+		return a, nil
+	}
+	number, err := strconv.ParseUint(revision, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	rev, err := a.GetRevision(number)
+	if err != nil {
+		return nil, err
+	}
+	return &rev.Article, nil
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"github.com/simpleelegant/notes/diff"
+	"github.com/simpleelegant/notes/resources"
+)
+
+// names of sides in conflict markers
+const (
+	mergeSaved = "saved"
+	mergeYours = "yours"
+)
+
+// merger merge fields of an update based on an older version of an
+// article with changes made to it since
+type merger struct {
+	current   *resources.Article
+	merged    map[string]string
+	conflicts []string
+}
+
+func newMerger(current *resources.Article) *merger {
+	return &merger{current: current, merged: make(map[string]string)}
+}
+
+// text merge changes of text field name line by line
+func (m *merger) text(name, base, saved, yours string) string {
+	merged, conflicts := yours, 0
+	if saved != base {
+		merged, conflicts = diff.Merge(base, saved, yours, mergeSaved, mergeYours)
+	}
+	if conflicts > 0 {
+		m.conflicts = append(m.conflicts, name)
+	}
+	m.merged[name] = merged
+	return merged
+}
+
+// field merge changes of field name as a whole
+func (m *merger) field(name, base, saved, yours string) string {
+	merged := yours
+	switch {
+	case yours == base:
+		merged = saved
+	case saved != base && saved != yours:
+		m.conflicts = append(m.conflicts, name)
+	}
+	m.merged[name] = merged
+	return merged
+}
+
+// conflict error of the merge
+func (m *merger) conflict() *ConflictError {
+	return &ConflictError{
+		Message:   "article was changed by another operation, merge the changes",
+		Version:   m.current.Version,
+		Conflicts: m.conflicts,
+		Merged:    m.merged,
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/simpleelegant/notes/resources"
+)
+
+func TestUpdateArticle(t *testing.T) {
+	openTestDatabase(t)
+	a := &resources.Article{Parent: resources.RootArticleID, Title: "a",
+		Content: "one\ntwo\nthree"}
+	if err := a.Create(); err != nil {
+		t.Fatal(err)
+	}
+	update := func(ifMatch, content string) (int, interface{}) {
+		return UpdateArticle(postForm(ifMatch, url.Values{
+			"id": {a.ID}, "uContent": {"true"}, "content": {content},
+		}))
+	}
+
+	if _, body := update("", "changed"); body != ErrPreconditionRequired {
+		t.Errorf("update without If-Match = %v", body)
+	}
+	if status, body := update(ETag(1), "ONE\ntwo\nthree"); status != http.StatusOK ||
+		body.(Versioned).Version != 2 {
+		t.Fatalf("update = %d, %v", status, body)
+	}
+
+	// based on version 1, merged with the change since
+	status, body := update(ETag(1), "one\ntwo\nTHREE")
+	if status != http.StatusOK || body.(Versioned).Body != "merged" {
+		t.Fatalf("update of an old version = %d, %v", status, body)
+	}
+	if got, _ := resources.GetArticle(a.ID); got.Content != "ONE\ntwo\nTHREE" {
+		t.Errorf("merged content = %q", got.Content)
+	}
+
+	// changed alike since
+	status, body = update(ETag(2), "ONE\ntwo\n3")
+	e, ok := body.(*ConflictError)
+	if status != http.StatusConflict || !ok || e.Version != 3 ||
+		len(e.Conflicts) != 1 || e.Conflicts[0] != "content" {
+		t.Errorf("update in conflict = %d, %v", status, body)
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/simpleelegant/notes/resources"
+)
+
+// ListNotebooks list notebooks
+func ListNotebooks(r *http.Request) (int, interface{}) {
+	return http.StatusOK, map[string]interface{}{
+		"notebooks": resources.ListNotebooks(),
+	}
+}
+
+// CreateNotebook create an empty notebook
+func CreateNotebook(r *http.Request) (int, interface{}) {
+	if err := resources.CreateNotebook(formValue(r, "name")); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, "created"
+}
+
+// RenameNotebook rename a notebook
+func RenameNotebook(r *http.Request) (int, interface{}) {
+	err := resources.RenameNotebook(formValue(r, "name"), formValue(r, "to"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, "renamed"
+}
+
+// SwitchNotebook make a notebook the current one, which is used by calls
+// naming no notebook
+func SwitchNotebook(r *http.Request) (int, interface{}) {
+	if err := resources.SwitchNotebook(formValue(r, "name")); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, "switched"
+}
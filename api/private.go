@@ -0,0 +1,103 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/simpleelegant/notes/conf"
+	"github.com/simpleelegant/notes/resources"
+)
+
+// SessionCookie names the cookie holding the session token of a client,
+// private articles are unlocked for a session
+const SessionCookie = "notes-session"
+
+// StartSession give the client of r a session token unless it has one
+func StartSession(w http.ResponseWriter, r *http.Request) error {
+	if session(r) != "" {
+		return nil
+	}
+	token := make([]byte, 20)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	c := &http.Cookie{
+		Name:     SessionCookie,
+		Value:    hex.EncodeToString(token),
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	}
+	http.SetCookie(w, c)
+	r.AddCookie(c)
+	return nil
+}
+
+// session get session token of the client of r, empty if it has none
+func session(r *http.Request) string {
+	c, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// SetPrivate make an article private with a password, or change its
+// password. It stays unlocked for now.
+func SetPrivate(r *http.Request) (int, interface{}) {
+	a, err := getArticleIfMatch(r, formValue(r, "id"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	password := r.FormValue("password")
+	if err := a.SetPrivate(password); err != nil {
+		return http.StatusBadRequest, err
+	}
+	err = resources.UnlockPrivate(session(r), a.ID, password, conf.PrivateUnlockTimeout)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, Versioned{a.Version, "private"}
+}
+
+// ClearPrivate make a private article public
+func ClearPrivate(r *http.Request) (int, interface{}) {
+	a, err := getArticleIfMatch(r, formValue(r, "id"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := a.ClearPrivate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, Versioned{a.Version, "public"}
+}
+
+// UnlockPrivate show a private article and its descendants, until they
+// lock again after a timeout
+func UnlockPrivate(r *http.Request) (int, interface{}) {
+	err := resources.UnlockPrivate(session(r), formValue(r, "id"), r.FormValue("password"),
+		conf.PrivateUnlockTimeout)
+	if err == resources.ErrWrongPassword {
+		return http.StatusForbidden, err
+	}
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, "unlocked"
+}
+
+// LockPrivate lock all private articles unlocked by the client now
+func LockPrivate(r *http.Request) (int, interface{}) {
+	resources.LockPrivate(session(r))
+	return http.StatusOK, "locked"
+}
+
+// getArticle get an article, a *resources.PrivateError is returned if it
+// is hidden from the client of r by a locked private article
+func getArticle(r *http.Request, id string) (*resources.Article, error) {
+	if err := resources.CheckUnlocked(session(r), id); err != nil {
+		return nil, err
+	}
+	return resources.GetArticle(id)
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/simpleelegant/notes/resources"
+)
+
+// QueryProperties find articles by their properties. Each parameter where
+// is a filter like "status=open", see resources.ParsePropertyFilter,
+// parameter under limits them to a subtree, and sort names the property
+// they are sorted by.
+func QueryProperties(r *http.Request) (int, interface{}) {
+	offset, limit, err := pagination(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := r.ParseForm(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	var filters []*resources.PropertyFilter
+	for _, s := range r.Form["where"] {
+		f, err := resources.ParsePropertyFilter(s)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		filters = append(filters, f)
+	}
+
+	under := formValue(r, "under")
+	if under != "" {
+		if err := resources.CheckUnlocked(session(r), under); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	result, err := resources.QueryProperties(session(r), under, filters,
+		formValue(r, "sort"), offset, limit)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, result
+}
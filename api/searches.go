@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/simpleelegant/notes/resources"
+)
+
+// ListSavedSearches list all saved searches
+func ListSavedSearches(r *http.Request) (int, interface{}) {
+	searches, err := resources.ListSavedSearches()
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, map[string]interface{}{"searches": searches}
+}
+
+// CreateSavedSearch save a named query, shown as a virtual article
+func CreateSavedSearch(r *http.Request) (int, interface{}) {
+	s := &resources.SavedSearch{
+		Name:   formValue(r, "name"),
+		Query:  formValue(r, "query"),
+		Parent: formValue(r, "parent"),
+	}
+	if err := s.Create(); err != nil {
+		return savedSearchError(err)
+	}
+	return http.StatusOK, Versioned{s.Version,
+		map[string]string{"id": s.ID, "node": s.NodeID()}}
+}
+
+// UpdateSavedSearch change name, query and parent of a saved search
+func UpdateSavedSearch(r *http.Request) (int, interface{}) {
+	if _, err := getSavedSearchIfMatch(r, formValue(r, "id")); err != nil {
+		return http.StatusBadRequest, err
+	}
+	s := &resources.SavedSearch{
+		ID:     formValue(r, "id"),
+		Name:   formValue(r, "name"),
+		Query:  formValue(r, "query"),
+		Parent: formValue(r, "parent"),
+	}
+	if err := s.Update(); err != nil {
+		return savedSearchError(err)
+	}
+	return http.StatusOK, Versioned{s.Version, s}
+}
+
+// DeleteSavedSearch delete a saved search
+func DeleteSavedSearch(r *http.Request) (int, interface{}) {
+	if _, err := getSavedSearchIfMatch(r, formValue(r, "id")); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := resources.DeleteSavedSearch(formValue(r, "id")); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, "deleted"
+}
+
+// getSavedSearchIfMatch get a saved search to change, of the version named
+// by If-Match
+func getSavedSearchIfMatch(r *http.Request, id string) (*resources.SavedSearch,
+	error) {
+	s, err := resources.GetSavedSearch(id)
+	if err != nil {
+		return nil, err
+	}
+	return s, matchVersion(r, "saved search", s.Version)
+}
+
+func savedSearchError(err error) (int, interface{}) {
+	if e, ok := err.(*resources.QueryError); ok {
+		// in JSON, so that UI can point out where the error is
+		return http.StatusBadRequest, *e
+	}
+	return http.StatusBadRequest, err
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/simpleelegant/notes/resources"
+)
+
+func TestSavedSearchNode(t *testing.T) {
+	openTestDatabase(t)
+	var want []string
+	for i := 0; i < 25; i++ {
+		want = append(want, fmt.Sprintf("apple %02d", i))
+	}
+	private := &resources.Article{Parent: resources.RootArticleID, Title: "private"}
+	if err := private.Create(); err != nil {
+		t.Fatal(err)
+	}
+	articles := []*resources.Article{
+		{Parent: private.ID, Title: "apple secret"},
+		{Parent: resources.RootArticleID, Title: "cherry"},
+	}
+	for _, title := range want {
+		articles = append(articles, &resources.Article{
+			Parent: resources.RootArticleID, Title: title})
+	}
+	for _, a := range articles {
+		if err := a.Create(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := private.SetPrivate("password"); err != nil {
+		t.Fatal(err)
+	}
+	s := &resources.SavedSearch{Name: "apples", Query: "apple"}
+	if err := s.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	// all matches are listed, not a page of them, and hidden ones are not
+	r := httptest.NewRequest(http.MethodGet, "/?sort=title&id="+s.NodeID(), nil)
+	code, body := GetArticle(r)
+	if code != http.StatusOK {
+		t.Fatalf("GetArticle = %v", body)
+	}
+	node := body.(Versioned).Body.(map[string]interface{})
+	got := []string{}
+	for _, a := range node["childrenOfCurrent"].([]*resources.ArticleTitle) {
+		got = append(got, a.Title)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sub-articles of the saved search = %v, want %v", got, want)
+	}
+
+	// and the saved search is among sub-articles of its parent
+	found := false
+	for _, a := range node["childrenOfParent"].([]*resources.ArticleTitle) {
+		found = found || a.ID == s.NodeID()
+	}
+	if !found {
+		t.Errorf("saved search is not under its parent")
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -8,12 +9,12 @@ import (
 	"os"
 	"time"
 
-	"github.com/boltdb/bolt"
 	"github.com/simpleelegant/notes/resources"
 )
 
-// Restore restore data
+// Restore restore data of a notebook
 func Restore(w http.ResponseWriter, r *http.Request) {
+	var repaired []*resources.Problem
 	err := func() error {
 		f, _, err := r.FormFile("file")
 		if err != nil {
@@ -37,16 +38,25 @@ func Restore(w http.ResponseWriter, r *http.Request) {
 			tf.Close()
 		}
 
-		// open file by boltdb
-		db, err := bolt.Open(tfn, 0600, nil)
+		// open file by boltdb, unlocking it if encrypted
+		db, err := resources.OpenBackup(tfn, r.FormValue("passphrase"))
 		if err != nil {
 			return err
 		}
+		defer db.Close()
 
-		// checking
+		// checking, then upgrade backups of older versions
 		if err := resources.CheckArticleCollection(db); err != nil {
 			return err
 		}
+		if err := resources.MigrateDatabase(db); err != nil {
+			return err
+		}
+
+		// repair the backup, never bring a broken tree in
+		if repaired, err = resources.CheckDatabase(db, true); err != nil {
+			return err
+		}
 
 		// really restore
 		return resources.RestoreArticlesFrom(db)
@@ -56,17 +66,73 @@ func Restore(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(repaired) > 0 {
+		replyInfo(w, fmt.Sprintf("Restored success, %d problems repaired.",
+			len(repaired)))
+		return
+	}
 	replyInfo(w, "Restored success.")
 }
 
-// Export export data
+// Export export data of a notebook
 func Export(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/octet-stream")
 	w.Header().Set("Content-Disposition",
-		fmt.Sprintf(`attachment; filename="notes.%s.db"`,
+		fmt.Sprintf(`attachment; filename="%s.%s.db"`, resources.NotebookInUse(),
 			time.Now().Format("2006-01-02.15_04_05.000Z")))
 	w.WriteHeader(http.StatusOK)
 	if err := resources.Export(w); err != nil {
 		log.Println(err)
 	}
 }
+
+// GetEncryption tell whether database is encrypted and locked
+func GetEncryption(r *http.Request) (int, interface{}) {
+	return http.StatusOK, map[string]bool{
+		"encrypted": resources.IsEncrypted(),
+		"locked":    resources.IsLocked(),
+	}
+}
+
+// Unlock unlock encrypted database with passphrase
+func Unlock(r *http.Request) (int, interface{}) {
+	err := resources.Unlock(r.FormValue("passphrase"))
+	if err == resources.ErrWrongPassphrase {
+		return http.StatusForbidden, err
+	}
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return GetEncryption(r)
+}
+
+// EnableEncryption encrypt database with passphrase
+func EnableEncryption(r *http.Request) (int, interface{}) {
+	passphrase := r.FormValue("passphrase")
+	if passphrase != r.FormValue("confirm") {
+		return http.StatusBadRequest,
+			errors.New("passphrase and its confirmation differ")
+	}
+	if err := resources.EnableEncryption(passphrase); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return GetEncryption(r)
+}
+
+// CheckDatabase list problems of the notebook in use
+func CheckDatabase(r *http.Request) (int, interface{}) {
+	problems, err := resources.CheckNotebook(false)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, map[string]interface{}{"problems": problems}
+}
+
+// RepairDatabase repair problems of the notebook in use, listing them
+func RepairDatabase(r *http.Request) (int, interface{}) {
+	problems, err := resources.CheckNotebook(true)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, map[string]interface{}{"problems": problems}
+}
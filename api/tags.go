@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/simpleelegant/notes/resources"
+)
+
+// ListTags list all tags with number of articles visible to the session
+func ListTags(r *http.Request) (int, interface{}) {
+	tags, err := resources.ListTags(session(r))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, map[string]interface{}{"tags": tags}
+}
+
+// GetArticlesByTag list articles having a tag
+func GetArticlesByTag(r *http.Request) (int, interface{}) {
+	articles, err := resources.GetArticlesByTag(session(r), formValue(r, "tag"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := resources.SortArticleTitles(articles,
+		formValue(r, "sort")); err != nil {
+		return http.StatusBadRequest, err
+	}
+	version, err := resources.TagVersion(formValue(r, "tag"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, Versioned{version,
+		map[string]interface{}{"articles": articles}}
+}
+
+// RenameTag rename a tag across all articles of the version named by
+// If-Match, merge into the new one if it exists
+func RenameTag(r *http.Request) (int, interface{}) {
+	version, err := resources.TagVersion(formValue(r, "from"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := matchVersion(r, "tag", version); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := resources.RenameTag(session(r), formValue(r, "from"),
+		formValue(r, "to")); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, "renamed"
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/simpleelegant/notes/resources"
+)
+
+// promptPrefix prefix of parameters answering prompts of a template
+const promptPrefix = "prompt:"
+
+// ListTemplates list templates with their prompts
+func ListTemplates(r *http.Request) (int, interface{}) {
+	list, err := resources.ListTemplates(session(r))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, map[string]interface{}{"templates": list}
+}
+
+// SetTemplate mark an article as a template, or not
+func SetTemplate(r *http.Request) (int, interface{}) {
+	a, err := getArticleIfMatch(r, formValue(r, "id"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := a.SetTemplate(formValue(r, "template") == "true"); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, Versioned{a.Version, "updated"}
+}
+
+// createFromTemplate create article a from template, with its
+// sub-articles if parameter subtree is "true". Parameters "prompt:Name"
+// answer prompts of template.
+func createFromTemplate(r *http.Request, a *resources.Article,
+	template string) (int, interface{}) {
+	if _, err := getArticle(r, template); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := r.ParseForm(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	answers := make(map[string]string)
+	for k, v := range r.Form {
+		if strings.HasPrefix(k, promptPrefix) && len(v) > 0 {
+			answers[strings.TrimPrefix(k, promptPrefix)] = strings.TrimSpace(v[0])
+		}
+	}
+	if formValue(r, "tags") == "" {
+		a.Tags = nil
+	}
+
+	err := a.CreateFromTemplate(session(r), template, formValue(r, "subtree") == "true",
+		answers)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, Versioned{a.Version, map[string]string{"id": a.ID}}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/simpleelegant/notes/conf"
+	"github.com/simpleelegant/notes/resources"
+)
+
+// ListTrash list deleted subtrees
+func ListTrash(r *http.Request) (int, interface{}) {
+	items, err := resources.ListTrash(session(r))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, map[string]interface{}{"items": items}
+}
+
+// RestoreTrash put a deleted subtree back to its original parent,
+// or to the specified parent
+func RestoreTrash(r *http.Request) (int, interface{}) {
+	item, err := resources.GetTrashItem(session(r), formValue(r, "id"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := matchVersion(r, "trash item", item.Version); err != nil {
+		return http.StatusBadRequest, err
+	}
+	parent := formValue(r, "parent")
+	if parent == "" {
+		parent = item.Parent
+	}
+	if err := resources.CheckUnlocked(session(r), parent); err != nil {
+		return http.StatusBadRequest, err
+	}
+	err = resources.RestoreFromTrash(item.ID, parent)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, "restored"
+}
+
+// PurgeTrash delete a subtree in trash permanently, of the version named
+// by If-Match, or all of them if all is true, except those hidden by
+// locked private articles
+func PurgeTrash(r *http.Request) (int, interface{}) {
+	if formValue(r, "all") == "true" {
+		if err := resources.PurgeTrashBefore(session(r), time.Now()); err != nil {
+			return http.StatusBadRequest, err
+		}
+		return http.StatusOK, "purged"
+	}
+
+	item, err := resources.GetTrashItem(session(r), formValue(r, "id"))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := matchVersion(r, "trash item", item.Version); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := resources.PurgeTrash(session(r), item.ID); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, "purged"
+}
+
+// StartTrashExpiry delete items which stay in trash too long from all
+// notebooks, now and then every interval in background, errors there are
+// logged
+func StartTrashExpiry(interval time.Duration) {
+	if conf.TrashRetentionDays <= 0 {
+		return
+	}
+	expireTrash()
+	go func() {
+		for range time.Tick(interval) {
+			expireTrash()
+		}
+	}()
+}
+
+func expireTrash() {
+	deadline := time.Now().AddDate(0, 0, -conf.TrashRetentionDays)
+	for _, n := range resources.ListNotebooks() {
+		var err error
+		e := resources.ChangeNotebook(n.Name, func() {
+			err = resources.ExpireTrash(deadline)
+		})
+		if e != nil {
+			err = e
+		}
+		if err != nil && err != resources.ErrLocked {
+			log.Printf("trash of notebook %s: %v", n.Name, err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/simpleelegant/notes/resources"
+)
+
+// ErrPreconditionRequired returned when a change is asked for without
+// If-Match, which must name the version changed. Every request which
+// changes an existing article, saved search or trash item needs it;
+// requests which only add new ones, or reorder siblings, change no
+// version and do not
+var ErrPreconditionRequired = errors.New("If-Match is required, naming the version to change")
+
+// ConflictError returned when an article was changed since the version
+// a request names by If-Match
+type ConflictError struct {
+	Message string `json:"error"`
+	// Version current version of the article
+	Version uint64 `json:"version"`
+	// Conflicts fields changed both by an update and since the version it
+	// is based on
+	Conflicts []string `json:"conflicts,omitempty"`
+	// Merged fields of the update merged with changes since, lines in
+	// conflict are between conflict markers
+	Merged map[string]string `json:"merged,omitempty"`
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+// Versioned body of a response about an article of version, which is sent
+// as ETag
+type Versioned struct {
+	Version uint64
+	Body    interface{}
+}
+
+// ETag format version of an article as an entity tag
+func ETag(version uint64) string {
+	return strconv.Quote(strconv.FormatUint(version, 10))
+}
+
+// checkVersion check that article a is of a version named by If-Match
+func checkVersion(r *http.Request, a *resources.Article) error {
+	return matchVersion(r, "article", a.Version)
+}
+
+// matchVersion check that a resource, named by what in errors, is of a
+// version named by If-Match
+func matchVersion(r *http.Request, what string, version uint64) error {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return ErrPreconditionRequired
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == ETag(version) {
+			return nil
+		}
+	}
+	return &ConflictError{
+		Message: what + " was changed by another operation",
+		Version: version,
+	}
+}
+
+// baseVersion get article a at the version named by If-Match, nil if it
+// does not name one version of a
+func baseVersion(r *http.Request, a *resources.Article) *resources.Article {
+	s, err := strconv.Unquote(strings.TrimPrefix(
+		strings.TrimSpace(r.Header.Get("If-Match")), "W/"))
+	if err != nil {
+		return nil
+	}
+	version, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return nil
+	}
+	base, err := a.AtVersion(version)
+	if err != nil {
+		return nil
+	}
+	return base
+}
+
+// getArticleIfMatch get an article to change, see getArticle and
+// checkVersion
+func getArticleIfMatch(r *http.Request, id string) (*resources.Article, error) {
+	a, err := getArticle(r, id)
+	if err != nil {
+		return nil, err
+	}
+	return a, checkVersion(r, a)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/simpleelegant/notes/resources"
+)
+
+func TestMatchVersion(t *testing.T) {
+	tests := []struct {
+		ifMatch string
+		want    error
+	}{
+		{"", ErrPreconditionRequired},
+		{`"3"`, nil},
+		{`W/"3"`, nil},
+		{`"1", "3"`, nil},
+		{"*", nil},
+		{`"2"`, &ConflictError{}},
+		{"3", &ConflictError{}},
+	}
+	for _, tt := range tests {
+		err := matchVersion(postForm(tt.ifMatch, nil), "article", 3)
+		switch e := err.(type) {
+		case *ConflictError:
+			if _, ok := tt.want.(*ConflictError); !ok || e.Version != 3 {
+				t.Errorf("If-Match %s: %v", tt.ifMatch, err)
+			}
+		default:
+			if err != tt.want {
+				t.Errorf("If-Match %s: %v, want %v", tt.ifMatch, err, tt.want)
+			}
+		}
+	}
+}
+
+func TestIfMatchRequired(t *testing.T) {
+	openTestDatabase(t)
+	var ids []string
+	for _, title := range []string{"a", "b", "c"} {
+		a := &resources.Article{Parent: resources.RootArticleID, Title: title}
+		if err := a.Create(); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, a.ID)
+	}
+	a, b, c := ids[0], ids[1], ids[2]
+
+	// copying and reordering change no version
+	if code, body := CopyArticle(postForm("", url.Values{"id": {a}, "parent": {b}})); code != http.StatusOK {
+		t.Errorf("copy without If-Match = %v", body)
+	}
+	if code, body := MoveArticle(postForm("", url.Values{"id": {a}, "direction": {"down"}})); code != http.StatusOK {
+		t.Errorf("move without If-Match = %v", body)
+	}
+	if _, body := DeleteArticle(postForm("", url.Values{"id": {b}})); body != ErrPreconditionRequired {
+		t.Errorf("delete without If-Match = %v", body)
+	}
+
+	for _, id := range []string{b, c} {
+		if code, body := DeleteArticle(postForm("*", url.Values{"id": {id}})); code != http.StatusOK {
+			t.Fatalf("delete = %v", body)
+		}
+	}
+	if _, body := PurgeTrash(postForm("", url.Values{"id": {b}})); body != ErrPreconditionRequired {
+		t.Errorf("purge without If-Match = %v", body)
+	}
+	item, err := resources.GetTrashItem("", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, body := PurgeTrash(postForm(ETag(item.Version+1), url.Values{"id": {b}}))
+	if _, ok := body.(*ConflictError); !ok {
+		t.Errorf("purge of another version = %v", body)
+	}
+	if code, body := PurgeTrash(postForm(ETag(item.Version), url.Values{"id": {b}})); code != http.StatusOK {
+		t.Errorf("purge = %v", body)
+	}
+	if code, body := PurgeTrash(postForm("", url.Values{"all": {"true"}})); code != http.StatusOK {
+		t.Errorf("purge all without If-Match = %v", body)
+	}
+	if items, _ := resources.ListTrash(""); len(items) != 0 {
+		t.Errorf("trash after purging all = %+v", items)
+	}
+}
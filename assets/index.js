@@ -1,6 +1,41 @@
+// notebook in use by this page, it is sent with every call
+var notebook = ''
+
+Vue.http.interceptors.push(function(request, next) {
+	if (notebook) { request.params.notebook = notebook }
+	next()
+})
+
+// ifMatch headers to change an article only if it is still of version
+function ifMatch(version) {
+	if (version === undefined) { return {} }
+	return {'If-Match': '"' + version + '"'}
+}
+
+function alertConflict(data) {
+	if (data.status === 409) {
+		alert('It was changed by another operation, reload it to see the changes.')
+		return
+	}
+	alert(data.bodyText)
+}
+
+// mergeConflict return fields merged with changes made by another
+// operation, for the user to resolve conflicts in them and save again
+function mergeConflict(data) {
+	if (data.status !== 409 || !data.body || !data.body.merged) {
+		alertConflict(data)
+		return null
+	}
+	alert('The article was changed by another operation. Changes are merged, ' +
+		'resolve conflicts in ' + data.body.conflicts.join(', ') +
+		' between <<<<<<< and >>>>>>>, then save again.')
+	return data.body.merged
+}
+
 var viewer = {
 	template: '#viewer',
-	props: ['id', 'title', 'html', 'diagramSVG'],
+	props: ['id', 'title', 'html', 'diagramSVG', 'tags', 'query', 'savedSearch', 'private', 'version', 'template', 'properties'],
 	methods: {
 		onEdit: function() { this.$emit('edit') },
 		onDelete: function() {
@@ -10,10 +45,18 @@ var viewer = {
 				alert('unexpected input')
 				return
 			}
-			this.$http.post('/articles/delete?id='+this.id)
+			this.$http.post('/articles/delete?id='+this.id, null, {headers: ifMatch(this.version)})
 				.then(function(data) {
 					this.$emit('deleted')
-				}, function(data) { alert(data.bodyText) })
+				}, alertConflict)
+		},
+		onDeleteSavedSearch: function() {
+			if (!confirm('Delete this saved search?')) { return }
+			this.$http.post('/searches/delete', {id: this.savedSearch},
+				{emulateJSON: true, headers: ifMatch(this.version)})
+				.then(function(data) {
+					this.$emit('deleted')
+				}, alertConflict)
 		},
 		onMove: function() {
 			var parent = prompt('enter an article id as new parent:','')
@@ -26,9 +69,17 @@ var viewer = {
 				id: this.id,
 				parent: parent,
 				uParent: true
+			}, {emulateJSON: true, headers: ifMatch(this.version)}).then(function(data) {
+					this.$emit('moved')
+				}, alertConflict)
+		},
+		onReorder: function(direction) {
+			this.$http.post('/articles/move', {
+				id: this.id,
+				direction: direction
 			}, {emulateJSON: true}).then(function(data) {
 					this.$emit('moved')
-				}, function(data) { alert(data.bodyText) })
+				}, alertConflict)
 		},
 		onCreate: function() {
 			if (!confirm('Create a sub-article?')) { return }
@@ -40,6 +91,67 @@ var viewer = {
 					this.$emit('update:newArticleID', data.body.id)
 				}, function(data) { alert(data.bodyText) })
 		},
+		onCreateFromTemplate: function() {
+			this.$http.get('/templates').then(function(data) {
+				var templates = data.body.templates || []
+				if (templates.length === 0) {
+					alert('No templates, mark an article as a template first.')
+					return
+				}
+				var list = templates.map(function(t, i) { return (i+1) + '. ' + t.title })
+				var n = prompt('Create a sub-article from template:\n' + list.join('\n'), '1')
+				if (n === null) { return }
+				var t = templates[parseInt(n, 10) - 1]
+				if (!t) {
+					alert('unexpected input')
+					return
+				}
+				var params = {parent: this.id, template: t.id,
+					subtree: confirm('Copy sub-articles of the template too?')}
+				for (var i = 0; i < t.prompts.length; i++) {
+					var answer = prompt(t.prompts[i] + ':', '')
+					if (answer === null) { return }
+					params['prompt:' + t.prompts[i]] = answer
+				}
+				this.$http.post('/articles/create', params, {emulateJSON: true})
+					.then(function(data) {
+						this.$emit('update:newArticleID', data.body.id)
+					}, function(data) { alert(data.bodyText) })
+			}, function(data) { alert(data.bodyText) })
+		},
+		onSetTemplate: function(template) {
+			this.$http.post('/articles/template', {id: this.id, template: template},
+				{emulateJSON: true, headers: ifMatch(this.version)})
+				.then(function(data) {
+					this.$emit('moved')
+				}, alertConflict)
+		},
+		onSetPrivate: function() {
+			var password = prompt('Hide content and sub-articles of this article behind a password:', '')
+			if (password === null) { return }
+			if (password === '' || password !== prompt('Enter the password again:', '')) {
+				alert('passwords are empty or differ')
+				return
+			}
+			this.$http.post('/articles/private', {id: this.id, password: password},
+				{emulateJSON: true, headers: ifMatch(this.version)})
+				.then(function(data) {
+					this.$emit('moved')
+				}, alertConflict)
+		},
+		onClearPrivate: function() {
+			if (!confirm('Remove the password, and show this article to anyone?')) { return }
+			this.$http.post('/articles/private/clear', {id: this.id},
+				{emulateJSON: true, headers: ifMatch(this.version)})
+				.then(function(data) {
+					this.$emit('moved')
+				}, alertConflict)
+		},
+		onLock: function() {
+			this.$http.post('/articles/lock').then(function(data) {
+				this.$emit('moved')
+			}, function(data) { alert(data.bodyText) })
+		},
 		onDraw: function() { this.$emit('draw') },
 		onSearch: function() { this.$emit('search') },
 		onExportRestore: function() { this.$emit('export-restore') }
@@ -58,11 +170,14 @@ var viewer = {
 
 var editor = {
 	template: '#editor',
-	props: ['id', 'title', 'content', 'contentMD5'],
+	props: ['id', 'title', 'content', 'contentMD5', 'tags', 'version'],
 	data: function() {
 		return {
 			titleEditable: this.title,
-			contentEditable: this.content
+			contentEditable: this.content,
+			tagsEditable: (this.tags || []).join(', '),
+			// version editing is based on, changes since are merged
+			baseVersion: this.version
 		}
 	},
 	methods: {
@@ -85,17 +200,25 @@ var editor = {
 		save: function(close) {
 			this.$http.post('/articles/update', {
 				id: this.id,
-				originalContentMD5: this.contentMD5,
 				title: this.titleEditable,
 				content: this.contentEditable,
+				tags: this.tagsEditable,
 				uTitle: true,
-				uContent: true
-			}, {emulateJSON: true}).then(function(data) {
+				uContent: true,
+				uTags: true
+			}, {emulateJSON: true, headers: ifMatch(this.baseVersion)}).then(function(data) {
 				this.$emit('updated')
 				if (close) {
 					this.$emit('close')
 				}
-			}, function(data) { alert(data.bodyText) })
+			}, function(data) {
+				var merged = mergeConflict(data)
+				if (!merged) { return }
+				this.titleEditable = merged.title
+				this.contentEditable = merged.content
+				this.tagsEditable = merged.tags
+				this.baseVersion = data.body.version
+			})
 		}
 	},
 	mounted: function() {
@@ -125,9 +248,13 @@ var articleView = {
 				diagram: '',
 				diagramSVG: '',
 				contentMD5: '',
-				diagramMD5: ''
+				diagramMD5: '',
+				tags: [],
+				version: 0,
+				template: false
 			},
-			childrenOfCurrent: []
+			childrenOfCurrent: [],
+			backlinks: []
 		}
 	},
 	methods: {
@@ -148,12 +275,51 @@ var articleView = {
 				this.childrenOfParent = data.body.childrenOfParent ||
 					[{id: this.current.id, title: this.current.title}]
 				this.childrenOfCurrent = data.body.childrenOfCurrent
+				this.backlinks = data.body.backlinks
 
 				if (edit) { this.edit = true }
 			}, function(data) {
+				if (data.status === 423) {
+					this.unlock(articleID, edit)
+					return
+				}
+				if (data.status === 403 && data.body && data.body.private) {
+					this.unlockPrivate(articleID, edit, data.body)
+					return
+				}
 				this.error = data.url+': '+data.bodyText
 			})
 		},
+		unlockPrivate: function(articleID, edit, locked) {
+			var password = prompt('"'+locked.title+'" is private, enter its password:')
+			if (password === null) {
+				this.error = '"'+locked.title+'" is private.'
+				return
+			}
+			this.$http.post('/articles/unlock', {id: locked.private, password: password}, {emulateJSON: true})
+				.then(function() {
+					this.error = ''
+					this.load(articleID, edit)
+				}, function(data) {
+					alert(data.bodyText)
+					this.unlockPrivate(articleID, edit, locked)
+				})
+		},
+		unlock: function(articleID, edit) {
+			var passphrase = prompt('The notes are encrypted, enter the passphrase to unlock them:')
+			if (passphrase === null) {
+				this.error = 'The notes are locked.'
+				return
+			}
+			this.$http.post('/unlock', {passphrase: passphrase}, {emulateJSON: true})
+				.then(function() {
+					this.error = ''
+					this.load(articleID, edit)
+				}, function(data) {
+					alert(data.bodyText)
+					this.unlock(articleID, edit)
+				})
+		},
 		onCloseDiagramEditor: function() {
 			this.$http.post('/md5', {data: this.current.diagram}, {emulateJSON: true})
 				.then(function(data) {
@@ -168,18 +334,25 @@ var articleView = {
 		onSaveDiagram: function() {
 			this.$http.post('/articles/update', {
 				id: this.current.id,
-				originalDiagramMD5: this.current.diagramMD5,
 				diagram: this.current.diagram,
 				uDiagram: true
-			}, {emulateJSON: true}).then(function(data) {
+			}, {emulateJSON: true, headers: ifMatch(this.current.version)}).then(function(data) {
 				this.load(this.current.id)
-			}, function(data) { alert(data.bodyText) })
+			}, function(data) {
+				var merged = mergeConflict(data)
+				if (!merged) { return }
+				this.current.diagram = merged.diagram
+				this.current.version = data.body.version
+			})
 		},
 		onSearch: function() { this.$emit('search') },
 		onExportRestore: function() { this.$emit('export-restore') }
 	},
 	created: function() {
 		this.load(this.article || '')
+		this.$watch('article', function (newValue, oldValue) {
+			this.show(newValue)
+		})
 		this.$watch('newArticleID', function (newValue, oldValue) {
 			this.load(this.newArticleID, true)
 		})
@@ -205,21 +378,54 @@ var search = {
 		return {
 			tips: '',
 			pattern: '',
-			titleMatches: null,
-			contentMatches: null
+			total: 0,
+			hits: null
 		}
 	},
 	methods: {
 		onBack: function() { this.$emit('back') },
 		onSubmit: function() {
+			this.search(0)
+		},
+		onMore: function() {
+			this.search(this.hits.length)
+		},
+		onSave: function() {
 			this.pattern = this.pattern.trim()
 			if (this.pattern === '') { return }
-			this.$http.post('/articles/search', {pattern: this.pattern}, {emulateJSON: true})
-				.then(function(data) {
-					this.titleMatches = data.body.titleMatches
-					this.contentMatches = data.body.contentMatches
-					this.tips = (!this.titleMatches && !this.contentMatches) ? 'No article matched' : ''
-				}, function(data) { alert(data.bodyText) })
+			var name = prompt('enter a name for this search:', this.pattern)
+			if (!name) { return }
+			this.$http.post('/searches/create', {
+				name: name,
+				query: this.pattern
+			}, {emulateJSON: true}).then(function(data) {
+					this.load(data.body.node)
+				}, function(data) {
+					if (data.body && data.body.error) {
+						this.tips = data.body.error + ' (at character ' + (data.body.position + 1) + ')'
+						return
+					}
+					alert(data.bodyText)
+				})
+		},
+		search: function(offset) {
+			this.pattern = this.pattern.trim()
+			if (this.pattern === '') { return }
+			this.$http.post('/articles/search', {
+				pattern: this.pattern,
+				offset: offset
+			}, {emulateJSON: true}).then(function(data) {
+					this.total = data.body.total
+					this.hits = offset ? this.hits.concat(data.body.hits) : data.body.hits
+					this.tips = this.total ? '' : 'No article matched'
+				}, function(data) {
+					if (data.body && data.body.error) {
+						// query syntax error
+						this.tips = data.body.error + ' (at character ' + (data.body.position + 1) + ')'
+						return
+					}
+					alert(data.bodyText)
+				})
 		},
 		load: function(id) { this.$emit('update:article', id) }
 	},
@@ -230,8 +436,34 @@ var search = {
 
 var exportRestore = {
 	template: '#export-restore',
+	data: function() {
+		return {
+			notebook: notebook,
+			encrypted: false,
+			passphrase: '',
+			confirm: ''
+		}
+	},
+	created: function() {
+		this.$http.get('/encryption').then(function(data) {
+			this.encrypted = data.body.encrypted
+		})
+	},
 	methods: {
-		onBack: function() { this.$emit('back') }
+		onBack: function() { this.$emit('back') },
+		onEncrypt: function(e) {
+			e.preventDefault()
+			if (!confirm('The passphrase can not be recovered, notes are lost if you forget it. Continue?')) { return }
+			this.$http.post('/encryption/enable', {
+				passphrase: this.passphrase,
+				confirm: this.confirm
+			}, {emulateJSON: true}).then(function(data) {
+				this.encrypted = data.body.encrypted
+				this.passphrase = this.confirm = ''
+			}, function(data) {
+				alert(data.bodyText)
+			})
+		}
 	}
 }
 
@@ -245,13 +477,58 @@ new Vue({
 	data: function() {
 		return {
 			page: 'article',
-			article: ''
+			article: location.hash.slice(1),
+			notebook: '',
+			notebooks: []
 		}
 	},
+	created: function() {
+		this.loadNotebooks()
+		// follow links to articles, such as wiki links in content
+		var vm = this
+		window.addEventListener('hashchange', function() {
+			vm.load(location.hash.slice(1))
+		})
+	},
 	methods: {
 		load: function(article) {
 			this.article = article
 			this.page = 'article'
+		},
+		loadNotebooks: function() {
+			this.$http.get('/notebooks').then(function(data) {
+				this.notebooks = data.body.notebooks
+				if (!this.notebook) {
+					var current = this.notebooks.filter(function(n) { return n.current })[0]
+					this.notebook = notebook = current.name
+				}
+			})
+		},
+		onSwitchNotebook: function() {
+			notebook = this.notebook
+			// open it next time too
+			this.$http.post('/notebooks/switch', {name: notebook}, {emulateJSON: true})
+			this.article = ''
+			this.page = 'article'
+		},
+		onCreateNotebook: function() {
+			var name = prompt('Name of the new notebook:', '')
+			if (!name) { return }
+			this.$http.post('/notebooks/create', {name: name}, {emulateJSON: true})
+				.then(function() {
+					this.notebook = name
+					this.onSwitchNotebook()
+					this.loadNotebooks()
+				}, function(data) { alert(data.bodyText) })
+		},
+		onRenameNotebook: function() {
+			var name = prompt('Rename notebook '+this.notebook+' to:', this.notebook)
+			if (!name || name === this.notebook) { return }
+			this.$http.post('/notebooks/rename', {name: this.notebook, to: name}, {emulateJSON: true})
+				.then(function() {
+					this.notebook = notebook = name
+					this.loadNotebooks()
+				}, function(data) { alert(data.bodyText) })
 		}
 	}
 })
@@ -13,14 +13,37 @@ import (
 var (
 	Host string
 	Port int
+
+	// TrashRetentionDays days to keep deleted articles in trash
+	TrashRetentionDays = 30
+	// TrashExpiryInterval how often trash is cleared of expired articles
+	TrashExpiryInterval = time.Hour
+
+	// StorageBackend where articles are kept, "bolt" or "memory"
+	StorageBackend = "bolt"
+
+	// MirrorFolder folder to mirror articles to as markdown files,
+	// empty for none
+	MirrorFolder string
+	// MirrorNotebook notebook mirrored, empty for the current one
+	MirrorNotebook string
+	// MirrorInterval how often the mirror folder is synchronized
+	MirrorInterval = 2 * time.Second
+
+	// PrivateUnlockTimeout how long a private article stays unlocked
+	PrivateUnlockTimeout = 10 * time.Minute
 )
 
+// PassphraseEnv names the environment variable an encrypted database is
+// unlocked with at startup, without it the database is unlocked in browser
+const PassphraseEnv = "NOTES_PASSPHRASE"
+
 // StartedAt server starting timestamp
 var StartedAt = time.Now()
 
 var (
-	confFile     *os.File
-	dataFilePath string
+	confFile   *os.File
+	dataFolder string
 )
 
 // GetHTTPAddress return the address at which HTTP serving
@@ -28,18 +51,18 @@ func GetHTTPAddress() string {
 	return fmt.Sprintf("%s:%d", Host, Port)
 }
 
-// GetDataFolder get folder in which data file placed
+// GetDataFolder get folder in which notebook files placed
 func GetDataFolder() string {
-	return dataFilePath
+	return dataFolder
 }
 
-// SetDataFolder set where to store database file and configuration file
+// SetDataFolder set where to store notebook files and configuration file
 func SetDataFolder(df string) error {
 	if !strings.HasSuffix(df, "/") {
 		df += "/"
 	}
 
-	dataFilePath = df + "notes.db"
+	dataFolder = df
 
 	// open configuration file
 	f, err := os.OpenFile(df+"conf.dat", os.O_RDWR|os.O_CREATE, 0666)
@@ -0,0 +1,117 @@
+// Package diff computes line based differences between texts.
+package diff
+
+import "strings"
+
+// operations of a line
+const (
+	Equal  = " "
+	Insert = "+"
+	Delete = "-"
+)
+
+// Line a line of a diff result
+type Line struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// String format line in unified diff style
+func (l Line) String() string {
+	return l.Op + l.Text
+}
+
+// SplitLines split text into lines, without line terminators
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Lines compute the line diff which turns a into b
+func Lines(a, b string) []Line {
+	return Compute(SplitLines(a), SplitLines(b))
+}
+
+// Compute compute the shortest edit script which turns a into b,
+// by Myers' algorithm
+func Compute(a, b []string) []Line {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	// v[k+offset] is the furthest x reached on diagonal k, in -max-1..max+1
+	offset := max + 1
+	v := make([]int, 2*offset+1)
+	// trace[d] keeps v before step d, only diagonals -d-1..d+1 which
+	// backtracking reads, so memory grows with the square of the distance
+	// rather than with its product by the length of texts
+	var trace [][]int
+	found := false
+	for d := 0; d <= max && !found; d++ {
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[offset-d-1:])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset]
+			} else {
+				x = v[k-1+offset] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+offset] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// backtrack
+	var out []Line
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		// at returns x on diagonal k before step d
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			out = append(out, Line{Op: Equal, Text: a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				out = append(out, Line{Op: Insert, Text: b[y]})
+			} else {
+				x--
+				out = append(out, Line{Op: Delete, Text: a[x]})
+			}
+		}
+	}
+
+	// reverse
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
@@ -0,0 +1,122 @@
+package diff
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"a", []string{"a"}},
+		{"a\n", []string{"a"}},
+		{"a\r\nb\r\n", []string{"a", "b"}},
+		{"a\n\nb", []string{"a", "", "b"}},
+	}
+	for _, tt := range tests {
+		if got := SplitLines(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitLines(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"", "", ""},
+		{"a\nb", "a\nb", " a  b"},
+		{"", "a\nb", "+a +b"},
+		{"a\nb", "", "-a -b"},
+		{"a\nb\nc", "a\nc", " a -b  c"},
+		{"a\nc", "a\nb\nc", " a +b  c"},
+		{"a\nb\nc", "a\nx\nc", " a -b +x  c"},
+		{"a\nb\nc\nd", "b\nc\nd\ne", "-a  b  c  d +e"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, l := range Lines(tt.a, tt.b) {
+			got = append(got, l.String())
+		}
+		if s := strings.Join(got, " "); s != tt.want {
+			t.Errorf("Lines(%q, %q) = %q, want %q", tt.a, tt.b, s, tt.want)
+		}
+	}
+}
+
+// TestComputeShortest check edit scripts of random texts turn a into b,
+// with as few edits as the longest common subsequence allows
+func TestComputeShortest(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, r.Intn(40))
+		for i := range lines {
+			lines[i] = string(rune('a' + r.Intn(4)))
+		}
+		return lines
+	}
+	for i := 0; i < 300; i++ {
+		a, b := random(), random()
+		var x, y []string
+		edits := 0
+		for _, l := range Compute(a, b) {
+			if l.Op != Insert {
+				x = append(x, l.Text)
+			}
+			if l.Op != Delete {
+				y = append(y, l.Text)
+			}
+			if l.Op != Equal {
+				edits++
+			}
+		}
+		if !equal(x, a) || !equal(y, b) {
+			t.Fatalf("Compute(%q, %q) does not turn a into b", a, b)
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); edits != want {
+			t.Fatalf("Compute(%q, %q) has %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+// lcs length of the longest common subsequence of a and b
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func BenchmarkComputeLong(b *testing.B) {
+	x := make([]string, 5000)
+	y := make([]string, 5000)
+	for i := range x {
+		x[i] = strings.Repeat("x", i%7)
+		y[i] = x[i]
+		if i%50 == 0 {
+			y[i] = "changed"
+		}
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Compute(x, y)
+	}
+}
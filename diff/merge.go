@@ -0,0 +1,103 @@
+package diff
+
+import "strings"
+
+// conflict markers, followed by names of the sides
+const (
+	MarkerA   = "<<<<<<< "
+	MarkerSep = "======="
+	MarkerB   = ">>>>>>> "
+)
+
+// Merge merge changes made to base in a and in b, line by line. Lines
+// changed differently in a and b are kept between conflict markers named
+// nameA and nameB, conflicts tells how many there are.
+func Merge(base, a, b, nameA, nameB string) (merged string, conflicts int) {
+	lines, conflicts := MergeLines(SplitLines(base), SplitLines(a),
+		SplitLines(b), nameA, nameB)
+	merged = strings.Join(lines, "\n")
+	if len(lines) > 0 && (strings.HasSuffix(a, "\n") || strings.HasSuffix(b, "\n")) {
+		merged += "\n"
+	}
+	return
+}
+
+// MergeLines three-way merge of lines, see Merge
+func MergeLines(base, a, b []string, nameA, nameB string) (
+	merged []string, conflicts int) {
+	ma, mb := matches(base, a), matches(base, b)
+
+	// o, i, j are positions in base, a and b
+	o, i, j := 0, 0, 0
+	for o < len(base) || i < len(a) || j < len(b) {
+		// lines unchanged on both sides
+		k := 0
+		for o+k < len(base) && ma[o+k] == i+k && mb[o+k] == j+k {
+			k++
+		}
+		if k > 0 {
+			merged = append(merged, base[o:o+k]...)
+			o, i, j = o+k, i+k, j+k
+			continue
+		}
+
+		// a chunk changed, up to the next line kept on both sides, which
+		// may be base[o] itself when lines are inserted before it
+		next, ni, nj := len(base), len(a), len(b)
+		for x := o; x < len(base); x++ {
+			if ma[x] >= 0 && mb[x] >= 0 {
+				next, ni, nj = x, ma[x], mb[x]
+				break
+			}
+		}
+		chunkO, chunkA, chunkB := base[o:next], a[i:ni], b[j:nj]
+		switch {
+		case equal(chunkA, chunkO):
+			merged = append(merged, chunkB...)
+		case equal(chunkB, chunkO), equal(chunkA, chunkB):
+			merged = append(merged, chunkA...)
+		default:
+			conflicts++
+			merged = append(merged, MarkerA+nameA)
+			merged = append(merged, chunkA...)
+			merged = append(merged, MarkerSep)
+			merged = append(merged, chunkB...)
+			merged = append(merged, MarkerB+nameB)
+		}
+		o, i, j = next, ni, nj
+	}
+	return
+}
+
+// matches map each line of base to the same line in b, or -1 if it is
+// deleted in b
+func matches(base, b []string) []int {
+	m := make([]int, len(base))
+	x, y := 0, 0
+	for _, l := range Compute(base, b) {
+		switch l.Op {
+		case Equal:
+			m[x] = y
+			x++
+			y++
+		case Delete:
+			m[x] = -1
+			x++
+		case Insert:
+			y++
+		}
+	}
+	return m
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
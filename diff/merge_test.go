@@ -0,0 +1,76 @@
+package diff
+
+import "testing"
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name          string
+		base, a, b    string
+		want          string
+		wantConflicts int
+	}{
+		{
+			name: "unchanged",
+			base: "x\ny\n", a: "x\ny\n", b: "x\ny\n",
+			want: "x\ny\n",
+		},
+		{
+			name: "changed on one side",
+			base: "x\ny\nz", a: "x\ny\nz", b: "x\nY\nz",
+			want: "x\nY\nz",
+		},
+		{
+			name: "changed apart on both sides",
+			base: "1\n2\n3\n4\n5", a: "one\n2\n3\n4\n5", b: "1\n2\n3\n4\nfive",
+			want: "one\n2\n3\n4\nfive",
+		},
+		{
+			name: "changed alike on both sides",
+			base: "x\ny\nz", a: "x\nY\nz", b: "x\nY\nz",
+			want: "x\nY\nz",
+		},
+		{
+			name: "inserted and deleted",
+			base: "x\ny\nz", a: "w\nx\ny\nz", b: "x\nz",
+			want: "w\nx\nz",
+		},
+		{
+			name: "inserted before a kept line on both sides",
+			base: "x\ny", a: "a\nx\ny", b: "b\nx\ny",
+			want: MarkerA + "saved\na\n" + MarkerSep + "\nb\n" + MarkerB +
+				"yours\nx\ny",
+			wantConflicts: 1,
+		},
+		{
+			name: "conflict",
+			base: "x\ny\nz", a: "x\nA\nz", b: "x\nB\nz",
+			want: "x\n" + MarkerA + "saved\nA\n" + MarkerSep + "\nB\n" +
+				MarkerB + "yours\nz",
+			wantConflicts: 1,
+		},
+		{
+			name: "both appended",
+			base: "x", a: "x\na", b: "x\nb",
+			want: "x\n" + MarkerA + "saved\na\n" + MarkerSep + "\nb\n" +
+				MarkerB + "yours",
+			wantConflicts: 1,
+		},
+		{
+			name: "from empty base",
+			base: "", a: "", b: "new\n",
+			want: "new\n",
+		},
+		{
+			name: "deleted on one side, unchanged on the other",
+			base: "x\ny", a: "", b: "x\ny",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		got, conflicts := Merge(tt.base, tt.a, tt.b, "saved", "yours")
+		if got != tt.want || conflicts != tt.wantConflicts {
+			t.Errorf("%s: Merge = %q, %d conflicts, want %q, %d", tt.name,
+				got, conflicts, tt.want, tt.wantConflicts)
+		}
+	}
+}
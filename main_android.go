@@ -100,11 +100,13 @@ func (c *logic) OnTouch(e touch.Event) {}
 func (c *logic) OnOtherEvent(e interface{}) {}
 
 func (c *logic) startHTTPServer() {
-	if err := resources.OpenDatabase(conf.GetDataFolder()); err != nil {
+	if err := resources.OpenDatabase(conf.StorageBackend,
+		conf.GetDataFolder(), ""); err != nil {
 		c.setScreenText(err.Error())
 		return
 	}
 
+	api.StartTrashExpiry(conf.TrashExpiryInterval)
 	registerRoutes(&assetsHandler{modTime: time.Now()})
 
 	addr := conf.GetHTTPAddress()
@@ -8,13 +8,31 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/simpleelegant/notes/api"
 	"github.com/simpleelegant/notes/conf"
 	"github.com/simpleelegant/notes/resources"
 )
 
+// check or repair notebooks, then exit, instead of serving
+var checkOnly, repairOnly bool
+
 func init() {
 	host := flag.String("host", "127.0.0.1", "server host")
 	port := flag.Int("port", 9030, "server port")
+	trashDays := flag.Int("trash-days", conf.TrashRetentionDays,
+		"days to keep deleted articles in trash")
+	storage := flag.String("storage", conf.StorageBackend,
+		"storage backend, bolt or memory (data is lost on exit)")
+	mirror := flag.String("mirror", "",
+		"folder to keep articles in as markdown files, synchronized both ways")
+	mirrorNotebook := flag.String("mirror-notebook", "",
+		"notebook to mirror, the current one if empty")
+	privateTimeout := flag.Duration("private-timeout", conf.PrivateUnlockTimeout,
+		"how long an unlocked private article stays unlocked")
+	flag.BoolVar(&checkOnly, "check", false,
+		"check notebooks for orphans, cycles and other problems, then exit")
+	flag.BoolVar(&repairOnly, "repair", false,
+		"repair problems of notebooks, moving orphans under \"Lost and found\", then exit")
 
 	// print usage
 	fmt.Println("----------------------------------------")
@@ -25,6 +43,11 @@ func init() {
 
 	conf.Host = *host
 	conf.Port = *port
+	conf.TrashRetentionDays = *trashDays
+	conf.StorageBackend = *storage
+	conf.MirrorFolder = *mirror
+	conf.MirrorNotebook = *mirrorNotebook
+	conf.PrivateUnlockTimeout = *privateTimeout
 
 	if err := conf.SetDataFolder("."); err != nil {
 		exit(err)
@@ -37,10 +60,24 @@ func exit(err error) {
 }
 
 func main() {
-	if err := resources.OpenDatabase(conf.GetDataFolder()); err != nil {
+	err := resources.OpenDatabase(conf.StorageBackend, conf.GetDataFolder(),
+		os.Getenv(conf.PassphraseEnv))
+	if err != nil {
 		exit(err)
 	}
+	if checkOnly || repairOnly {
+		checkNotebooks(repairOnly)
+		return
+	}
+	if conf.MirrorFolder != "" {
+		_, err := resources.StartMirror(conf.MirrorNotebook, conf.MirrorFolder,
+			conf.MirrorInterval)
+		if err != nil {
+			exit(err)
+		}
+	}
 
+	api.StartTrashExpiry(conf.TrashExpiryInterval)
 	registerRoutes(http.FileServer(http.Dir("./")))
 
 	addr := conf.GetHTTPAddress()
@@ -51,3 +88,33 @@ func main() {
 		exit(err)
 	}
 }
+
+// checkNotebooks check or repair all notebooks, printing problems found.
+// It exits with status 1 if problems are left.
+func checkNotebooks(repair bool) {
+	left := false
+	for _, n := range resources.ListNotebooks() {
+		var problems []*resources.Problem
+		var err error
+		if e := resources.ChangeNotebook(n.Name, func() {
+			problems, err = resources.CheckNotebook(repair)
+		}); e != nil {
+			err = e
+		}
+		if err != nil {
+			fmt.Printf("notebook %s: %v\n", n.Name, err)
+			left = true
+			continue
+		}
+		fmt.Printf("notebook %s: %d problems\n", n.Name, len(problems))
+		for _, p := range problems {
+			fmt.Println("  " + p.String())
+		}
+		if !repair && len(problems) > 0 {
+			left = true
+		}
+	}
+	if left {
+		os.Exit(1)
+	}
+}
@@ -1,14 +1,12 @@
 package resources
 
 import (
-	"bytes"
 	"crypto/md5"
 	"crypto/rand"
 	"errors"
 	"fmt"
-	"strings"
+	"time"
 
-	"github.com/boltdb/bolt"
 	"github.com/russross/blackfriday"
 )
 
@@ -16,12 +14,23 @@ var (
 	articleCollectionName = []byte("Document")
 
 	// article field names
-	fParent  = []byte("ParentId")
-	fTitle   = []byte("Title")
-	fContent = []byte("Content")
-	fDiagram = []byte("Diagram")
+	fParent    = []byte("ParentId")
+	fTitle     = []byte("Title")
+	fContent   = []byte("Content")
+	fDiagram   = []byte("Diagram")
+	fCreatedAt = []byte("CreatedAt")
+	fUpdatedAt = []byte("UpdatedAt")
+	fTags      = []byte("Tags")
 )
 
+// auxiliaryCollections are copied as they are when restoring
+var auxiliaryCollections = [][]byte{
+	historyCollectionName,
+	trashCollectionName,
+	attachmentsCollectionName,
+	savedSearchesCollectionName,
+}
+
 // RootArticleID root article's id
 const RootArticleID = "53e7d496-a969-4dce-9901-e21ec772b53b"
 
@@ -34,12 +43,20 @@ var (
 // Article resource
 type Article struct {
 	ID, Parent, Title, Content, Diagram string
+	CreatedAt, UpdatedAt                time.Time
+	Tags                                []string
+	// Private if it has a password, see SetPrivate
+	Private bool
+	// Version increases with each change of the article
+	Version uint64
+	// Template if new articles can be created from it, see SetTemplate
+	Template bool
 }
 
 // GetArticle get an article by its id
 func GetArticle(id string) (*Article, error) {
 	a := &Article{ID: id}
-	err := db.View(func(tx *bolt.Tx) error {
+	err := db.View(func(tx Tx) error {
 		c, err := articleCollection(tx)
 		if err != nil {
 			return err
@@ -53,82 +70,79 @@ func GetArticle(id string) (*Article, error) {
 		a.Title = string(b.Get(fTitle))
 		a.Content = string(b.Get(fContent))
 		a.Diagram = string(b.Get(fDiagram))
+		a.CreatedAt = parseTime(b.Get(fCreatedAt))
+		a.UpdatedAt = parseTime(b.Get(fUpdatedAt))
+		a.Tags = decodeTags(b.Get(fTags))
+		a.Private = b.Get(fPrivate) != nil
+		a.Version = decodeVersion(b.Get(fVersion))
+		a.Template = b.Get(fTemplate) != nil
 		return nil
 	})
 	return a, err
 }
 
-// SearchArticles search articles by title or content pattern
-func SearchArticles(pattern string) (
-	titleMatches, contentMatches []*ArticleTitle, e error) {
-	p := []byte(strings.ToLower(pattern))
-	e = db.View(func(tx *bolt.Tx) error {
-		c, err := articleCollection(tx)
-		if err != nil {
-			return err
-		}
-
-		cursor := c.Cursor()
-		for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
-			b := c.Bucket(k)
-			title := b.Get(fTitle)
-			if bytes.Contains(bytes.ToLower(title), p) {
-				titleMatches = append(titleMatches,
-					&ArticleTitle{ID: string(k), Title: string(title)})
-			} else if bytes.Contains(bytes.ToLower(b.Get(fContent)), p) {
-				contentMatches = append(contentMatches,
-					&ArticleTitle{ID: string(k), Title: string(title)})
-			}
-		}
-
-		return nil
-	})
-
-	return
-}
-
 // Create create an article
 func (a *Article) Create() error {
+	return db.Update(a.create)
+}
+
+// create create an article in tx, giving it an id
+func (a *Article) create(tx Tx) error {
 	var err error
 	a.ID, err = newID()
 	if err != nil {
 		return err
 	}
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = a.CreatedAt
 
-	return db.Update(func(tx *bolt.Tx) error {
-		c, err := articleCollection(tx)
-		if err != nil {
-			return err
-		}
+	c, err := articleCollection(tx)
+	if err != nil {
+		return err
+	}
 
-		// if article already exists
-		if c.Bucket([]byte(a.ID)) != nil {
-			return errors.New("article already exists")
-		}
+	// if article already exists
+	if c.Bucket([]byte(a.ID)) != nil {
+		return errors.New("article already exists")
+	}
 
-		b, err := c.CreateBucket([]byte(a.ID))
-		if err != nil {
-			return err
-		}
-		if err = b.Put(fParent, []byte(a.Parent)); err != nil {
-			return err
-		}
-		if err = b.Put(fTitle, []byte(a.Title)); err != nil {
-			return err
-		}
-		if err = b.Put(fContent, []byte(a.Content)); err != nil {
-			return err
-		}
-		if err = b.Put(fDiagram, []byte(a.Diagram)); err != nil {
-			return err
-		}
-		return nil
-	})
+	b, err := c.CreateBucket([]byte(a.ID))
+	if err != nil {
+		return err
+	}
+	if err = b.Put(fParent, []byte(a.Parent)); err != nil {
+		return err
+	}
+	if err = b.Put(fTitle, []byte(a.Title)); err != nil {
+		return err
+	}
+	if err = b.Put(fContent, []byte(a.Content)); err != nil {
+		return err
+	}
+	if err = b.Put(fDiagram, []byte(a.Diagram)); err != nil {
+		return err
+	}
+	if err = b.Put(fCreatedAt, formatTime(a.CreatedAt)); err != nil {
+		return err
+	}
+	if err = b.Put(fUpdatedAt, formatTime(a.UpdatedAt)); err != nil {
+		return err
+	}
+	if err = b.Put(fTags, encodeTags(a.Tags)); err != nil {
+		return err
+	}
+	if a.Version, err = bumpVersion(b); err != nil {
+		return err
+	}
+	if err = indexArticle(tx, []byte(a.ID), b); err != nil {
+		return err
+	}
+	return addChild(tx, a.Parent, a.ID)
 }
 
 // Update update an article
-func (a *Article) Update(parent, title, content, diagram bool) error {
-	return db.Update(func(tx *bolt.Tx) error {
+func (a *Article) Update(parent, title, content, diagram, tags bool) error {
+	return db.Update(func(tx Tx) error {
 		c, err := articleCollection(tx)
 		if err != nil {
 			return err
@@ -138,10 +152,35 @@ func (a *Article) Update(parent, title, content, diagram bool) error {
 			return ErrArticleNotFound
 		}
 
+		if err = unindexArticle(tx, []byte(a.ID), b); err != nil {
+			return err
+		}
+
+		// keep the version being replaced
+		if parent || title || content || diagram || tags {
+			if err = saveRevision(tx, a.ID, b); err != nil {
+				return err
+			}
+			a.UpdatedAt = time.Now()
+			if err = b.Put(fUpdatedAt, formatTime(a.UpdatedAt)); err != nil {
+				return err
+			}
+			if a.Version, err = bumpVersion(b); err != nil {
+				return err
+			}
+		}
+
 		if parent {
+			err = removeChild(tx, string(b.Get(fParent)), a.ID)
+			if err != nil {
+				return err
+			}
 			if err = b.Put(fParent, []byte(a.Parent)); err != nil {
 				return err
 			}
+			if err = addChild(tx, a.Parent, a.ID); err != nil {
+				return err
+			}
 		}
 		if title {
 			if err = b.Put(fTitle, []byte(a.Title)); err != nil {
@@ -158,19 +197,27 @@ func (a *Article) Update(parent, title, content, diagram bool) error {
 				return err
 			}
 		}
-		return nil
+		if tags {
+			if err = b.Put(fTags, encodeTags(a.Tags)); err != nil {
+				return err
+			}
+		}
+		return indexArticle(tx, []byte(a.ID), b)
 	})
 }
 
 // IsAncestorOf check relationship
 func (a *Article) IsAncestorOf(testID string) (yes bool, err error) {
-	err = db.View(func(tx *bolt.Tx) error {
+	err = db.View(func(tx Tx) error {
 		c, err := articleCollection(tx)
 		if err != nil {
 			return err
 		}
 
-		for {
+		// a broken database may have a cycle of parents
+		seen := make(map[string]bool)
+		for !seen[testID] {
+			seen[testID] = true
 			t := c.Bucket([]byte(testID))
 			if t == nil {
 				break
@@ -193,37 +240,63 @@ func (a *Article) IsAncestorOf(testID string) (yes bool, err error) {
 
 // Delete article by id, without sub-articles
 func (a *Article) Delete() error {
-	return db.Update(func(tx *bolt.Tx) error {
+	return db.Update(func(tx Tx) error {
 		c, err := articleCollection(tx)
 		if err != nil {
 			return err
 		}
-		if c.Bucket([]byte(a.ID)) == nil {
+		b := c.Bucket([]byte(a.ID))
+		if b == nil {
 			return nil
 		}
+		if err := removeChild(tx, string(b.Get(fParent)), a.ID); err != nil {
+			return err
+		}
+		if err := unindexArticle(tx, []byte(a.ID), b); err != nil {
+			return err
+		}
+		if err := deleteHistory(tx, a.ID); err != nil {
+			return err
+		}
+		if err := deleteAttachments(tx, a.ID); err != nil {
+			return err
+		}
 		return c.DeleteBucket([]byte(a.ID))
 	})
 }
 
+// ArticleTitle brief of an article
 type ArticleTitle struct {
-	ID    string `json:"id"`
-	Title string `json:"title"`
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
-// GetSubArticles get sub-articles
-func (a *Article) GetSubArticles() (subs []*ArticleTitle, err error) {
-	err = db.View(func(tx *bolt.Tx) error {
+func articleTitle(id []byte, b Bucket) *ArticleTitle {
+	return &ArticleTitle{
+		ID:        string(id),
+		Title:     string(b.Get(fTitle)),
+		CreatedAt: parseTime(b.Get(fCreatedAt)),
+		UpdatedAt: parseTime(b.Get(fUpdatedAt)),
+	}
+}
+
+// GetSubArticles get sub-articles, none while a is hidden from session by
+// a locked private article
+func (a *Article) GetSubArticles(session string) (subs []*ArticleTitle, err error) {
+	err = db.View(func(tx Tx) error {
 		c, err := articleCollection(tx)
 		if err != nil {
 			return err
 		}
+		if newPrivacy(c, session).hidden(a.ID) {
+			return nil
+		}
 
-		cursor := c.Cursor()
-		for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
-			b := c.Bucket(k)
-			if string(b.Get(fParent)) == a.ID {
-				subs = append(subs,
-					&ArticleTitle{ID: string(k), Title: string(b.Get(fTitle))})
+		for _, id := range childrenOf(tx, a.ID) {
+			if b := c.Bucket([]byte(id)); b != nil {
+				subs = append(subs, articleTitle([]byte(id), b))
 			}
 		}
 		return nil
@@ -237,48 +310,23 @@ func (a *Article) MD5() (contentMD5, diagramMD5 string) {
 		fmt.Sprintf("%x", md5.Sum([]byte(a.Diagram)))
 }
 
-// ContentHTML convert content which in markdown to HTML
-func (a *Article) ContentHTML() []byte {
-	return blackfriday.MarkdownCommon([]byte(a.Content))
+// ContentHTML convert content which in markdown to HTML,
+// wiki links and attachment references are resolved, the front-matter
+// of properties is left out. Links to articles hidden from session by
+// locked private articles are rendered as broken.
+func (a *Article) ContentHTML(session string) []byte {
+	_, body := ParseProperties(a.Content)
+	content := renderAttachmentRefs(a.ID, body)
+	return blackfriday.MarkdownCommon([]byte(renderWikiLinks(session, content)))
 }
 
-func initArticleCollection() error {
-	return db.Update(func(tx *bolt.Tx) error {
-		c, err := articleCollection(tx)
-		if err != nil {
-			if err != ErrNoArticleCollection {
-				return err
-			}
-			// create collection
-			c, err = tx.CreateBucket(articleCollectionName)
-			if err != nil {
-				return err
-			}
-		}
-
-		// create root article if not exists
-		if c.Bucket([]byte(RootArticleID)) == nil {
-			b, err := c.CreateBucket([]byte(RootArticleID))
-			if err != nil {
-				return err
-			}
-			err = b.Put(fTitle, []byte("First Article"))
-			if err != nil {
-				return err
-			}
-			err = b.Put(fContent, []byte("You can edit this article."))
-			if err != nil {
-				return err
-			}
+// CheckArticleCollection check if black have valid structure for Article,
+// and is not written by a newer version
+func CheckArticleCollection(black Storage) error {
+	return black.View(func(tx Tx) error {
+		if _, err := schemaVersion(tx); err != nil {
+			return err
 		}
-
-		return nil
-	})
-}
-
-// CheckArticleCollection check if black have valid structure for Article
-func CheckArticleCollection(black *bolt.DB) error {
-	return black.View(func(tx *bolt.Tx) error {
 		c, err := articleCollection(tx)
 		if err != nil {
 			return err
@@ -287,15 +335,14 @@ func CheckArticleCollection(black *bolt.DB) error {
 			return errors.New("no root article in database")
 		}
 
-		// TODO more check
-
+		// the rest is checked by CheckDatabase
 		return nil
 	})
 }
 
 // RestoreArticlesFrom restore article collection from src
-func RestoreArticlesFrom(src *bolt.DB) error {
-	return db.Update(func(tx *bolt.Tx) error {
+func RestoreArticlesFrom(src Storage) error {
+	return db.Update(func(tx Tx) error {
 		c, err := articleCollection(tx)
 		if err != nil {
 			return err
@@ -309,13 +356,37 @@ func RestoreArticlesFrom(src *bolt.DB) error {
 			}
 		}
 
+		// clean auxiliary collections
+		for _, name := range auxiliaryCollections {
+			if tx.Bucket(name) != nil {
+				if err := tx.DeleteBucket(name); err != nil {
+					return err
+				}
+			}
+		}
+
 		// copy articles from src
-		return src.View(func(tx *bolt.Tx) error {
-			x, err := articleCollection(tx)
+		return src.View(func(stx Tx) error {
+			x, err := articleCollection(stx)
 			if err != nil {
 				return err
 			}
 
+			// copy auxiliary collections if any
+			for _, name := range auxiliaryCollections {
+				x := stx.Bucket(name)
+				if x == nil {
+					continue
+				}
+				y, err := tx.CreateBucket(name)
+				if err != nil {
+					return err
+				}
+				if err = copyBucket(y, x); err != nil {
+					return err
+				}
+			}
+
 			cursor := x.Cursor()
 			for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
 				y := x.Bucket(k)
@@ -335,14 +406,51 @@ func RestoreArticlesFrom(src *bolt.DB) error {
 				if err = b.Put(fDiagram, y.Get(fDiagram)); err != nil {
 					return err
 				}
+				if err = b.Put(fCreatedAt, y.Get(fCreatedAt)); err != nil {
+					return err
+				}
+				if err = b.Put(fUpdatedAt, y.Get(fUpdatedAt)); err != nil {
+					return err
+				}
+				if err = b.Put(fTags, y.Get(fTags)); err != nil {
+					return err
+				}
+				for _, f := range [][]byte{fPrivate, fVersion, fTemplate} {
+					if v := y.Get(f); v != nil {
+						if err = b.Put(f, v); err != nil {
+							return err
+						}
+					}
+				}
+			}
+			if err := rebuildIndexes(tx); err != nil {
+				return err
 			}
 
-			return nil
+			// keep sub-articles' order of src
+			return buildChildrenIndex(tx, stx.Bucket(childrenCollectionName))
 		})
 	})
 }
 
-func articleCollection(tx *bolt.Tx) (*bolt.Bucket, error) {
+// copyBucket copy all keys and nested buckets of src into dst
+func copyBucket(dst, src Bucket) error {
+	return src.ForEach(func(k, v []byte) error {
+		if v != nil {
+			return dst.Put(k, v)
+		}
+		b, err := dst.CreateBucketIfNotExists(k)
+		if err != nil {
+			return err
+		}
+		if err = b.SetSequence(src.Bucket(k).Sequence()); err != nil {
+			return err
+		}
+		return copyBucket(b, src.Bucket(k))
+	})
+}
+
+func articleCollection(tx Tx) (Bucket, error) {
 	c := tx.Bucket(articleCollectionName)
 	if c == nil {
 		return nil, ErrNoArticleCollection
@@ -350,6 +458,20 @@ func articleCollection(tx *bolt.Tx) (*bolt.Bucket, error) {
 	return c, nil
 }
 
+// formatTime encode t for storing, zero time is stored as empty
+func formatTime(t time.Time) []byte {
+	if t.IsZero() {
+		return []byte{}
+	}
+	return []byte(t.Format(time.RFC3339Nano))
+}
+
+// parseTime decode a stored time, returns zero time for absent value
+func parseTime(v []byte) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, string(v))
+	return t
+}
+
 func newID() (string, error) {
 	const (
 		pool   = "1234567890abcdefghijklmnopqrstuvwxyz"
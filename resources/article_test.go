@@ -0,0 +1,32 @@
+package resources
+
+import "testing"
+
+// openTestDatabase open a notebook in memory, as db
+func openTestDatabase(t *testing.T) {
+	if err := OpenDatabase(MemoryBackend, t.TempDir(), ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createArticle create an article titled title under parent
+func createArticle(t *testing.T, parent, title string) *Article {
+	a := &Article{Parent: parent, Title: title, Content: title + " content"}
+	if err := a.Create(); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// subTitles titles of sub-articles of id, in order
+func subTitles(t *testing.T, id string) []string {
+	subs, err := (&Article{ID: id}).GetSubArticles("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := []string{}
+	for _, s := range subs {
+		titles = append(titles, s.Title)
+	}
+	return titles
+}
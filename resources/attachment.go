@@ -0,0 +1,206 @@
+package resources
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// attachmentsCollectionName names the collection of attachments, grouped
+// by id of the article they belong to
+var attachmentsCollectionName = []byte("Attachments")
+
+// attachment field names
+var (
+	fName        = []byte("Name")
+	fContentType = []byte("ContentType")
+	fData        = []byte("Data")
+)
+
+// ErrAttachmentNotFound returned when an attachment does not exist
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// Attachment a file attached to an article
+type Attachment struct {
+	ID          string    `json:"id"`
+	Article     string    `json:"article"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"contentType"`
+	Size        int       `json:"size"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Data        []byte    `json:"-"`
+}
+
+// Create store attachment, its id is generated
+func (t *Attachment) Create() error {
+	var err error
+	t.ID, err = newID()
+	if err != nil {
+		return err
+	}
+	t.CreatedAt = time.Now()
+	t.Size = len(t.Data)
+
+	return db.Update(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		if c.Bucket([]byte(t.Article)) == nil {
+			return ErrArticleNotFound
+		}
+
+		x, err := tx.CreateBucketIfNotExists(attachmentsCollectionName)
+		if err != nil {
+			return err
+		}
+		y, err := x.CreateBucketIfNotExists([]byte(t.Article))
+		if err != nil {
+			return err
+		}
+		b, err := y.CreateBucket([]byte(t.ID))
+		if err != nil {
+			return err
+		}
+		if err = b.Put(fName, []byte(t.Name)); err != nil {
+			return err
+		}
+		if err = b.Put(fContentType, []byte(t.ContentType)); err != nil {
+			return err
+		}
+		if err = b.Put(fCreatedAt, formatTime(t.CreatedAt)); err != nil {
+			return err
+		}
+		return b.Put(fData, t.Data)
+	})
+}
+
+// Delete delete attachment
+func (t *Attachment) Delete() error {
+	return db.Update(func(tx Tx) error {
+		y := articleAttachments(tx, t.Article)
+		if y == nil || y.Bucket([]byte(t.ID)) == nil {
+			return ErrAttachmentNotFound
+		}
+		return y.DeleteBucket([]byte(t.ID))
+	})
+}
+
+// GetAttachment get an attachment with its data
+func GetAttachment(article, id string) (*Attachment, error) {
+	var t *Attachment
+	err := db.View(func(tx Tx) error {
+		y := articleAttachments(tx, article)
+		if y == nil || y.Bucket([]byte(id)) == nil {
+			return ErrAttachmentNotFound
+		}
+		b := y.Bucket([]byte(id))
+		t = attachment(article, []byte(id), b)
+		t.Data = append([]byte{}, b.Get(fData)...)
+		return nil
+	})
+	return t, err
+}
+
+// Attachments list attachments of article, without their data
+func (a *Article) Attachments() (list []*Attachment, err error) {
+	err = db.View(func(tx Tx) error {
+		y := articleAttachments(tx, a.ID)
+		if y == nil {
+			return nil
+		}
+		return y.ForEach(func(k, _ []byte) error {
+			list = append(list, attachment(a.ID, k, y.Bucket(k)))
+			return nil
+		})
+	})
+	return
+}
+
+// attachmentRef matches a markdown link destination referring to an
+// attachment of the article, by its id or name, such as
+// ![screenshot](attachment:screen.png)
+var attachmentRef = regexp.MustCompile(`\]\(attachment:([^)\s]+)\)`)
+
+// renderAttachmentRefs replace attachment references in content with
+// download URLs
+func renderAttachmentRefs(article, content string) string {
+	if !attachmentRef.MatchString(content) {
+		return content
+	}
+
+	names := make(map[string]string)
+	db.View(func(tx Tx) error {
+		y := articleAttachments(tx, article)
+		if y == nil {
+			return nil
+		}
+		return y.ForEach(func(k, _ []byte) error {
+			names[string(y.Bucket(k).Get(fName))] = string(k)
+			names[string(k)] = string(k)
+			return nil
+		})
+	})
+
+	return attachmentRef.ReplaceAllStringFunc(content, func(m string) string {
+		// name may be escaped, such as "my%20screen.png"
+		ref := attachmentRef.FindStringSubmatch(m)[1]
+		if unescaped, err := url.PathUnescape(ref); err == nil {
+			ref = unescaped
+		}
+		id, ok := names[ref]
+		if !ok {
+			return m
+		}
+		return "](" + AttachmentURL(article, id) + ")"
+	})
+}
+
+// AttachmentURL return download URL of an attachment
+func AttachmentURL(article, id string) string {
+	return "/attachments/get?notebook=" + url.QueryEscape(NotebookInUse()) +
+		"&article=" + url.QueryEscape(article) + "&id=" + url.QueryEscape(id)
+}
+
+// deleteAttachments remove all attachments of article
+func deleteAttachments(tx Tx, article string) error {
+	x := tx.Bucket(attachmentsCollectionName)
+	if x == nil || x.Bucket([]byte(article)) == nil {
+		return nil
+	}
+	return x.DeleteBucket([]byte(article))
+}
+
+// copyAttachments copy all attachments of article src to article dst,
+// attachments keep their ids since they are scoped by article
+func copyAttachments(tx Tx, src, dst string) error {
+	y := articleAttachments(tx, src)
+	if y == nil {
+		return nil
+	}
+	z, err := tx.Bucket(attachmentsCollectionName).CreateBucketIfNotExists([]byte(dst))
+	if err != nil {
+		return err
+	}
+	return copyBucket(z, y)
+}
+
+func articleAttachments(tx Tx, article string) Bucket {
+	x := tx.Bucket(attachmentsCollectionName)
+	if x == nil {
+		return nil
+	}
+	return x.Bucket([]byte(article))
+}
+
+func attachment(article string, id []byte, b Bucket) *Attachment {
+	return &Attachment{
+		ID:          string(id),
+		Article:     article,
+		Name:        string(b.Get(fName)),
+		ContentType: string(b.Get(fContentType)),
+		Size:        len(b.Get(fData)),
+		CreatedAt:   parseTime(b.Get(fCreatedAt)),
+	}
+}
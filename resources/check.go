@@ -0,0 +1,440 @@
+package resources
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// kinds of problems found by CheckDatabase
+const (
+	ProblemInvalidID     = "invalid-id"
+	ProblemMissingField  = "missing-field"
+	ProblemDuplicateRoot = "duplicate-root"
+	ProblemOrphan        = "orphan"
+	ProblemCycle         = "cycle"
+	ProblemChildrenIndex = "children-index"
+)
+
+// LostAndFoundTitle title of the article orphans are moved under
+const LostAndFoundTitle = "Lost and found"
+
+// validIDRegexp matches ids made by newID and the root article's, ids must
+// be safe in URLs and never look like ids of saved searches
+var validIDRegexp = regexp.MustCompile(`^[0-9A-Za-z-]{1,64}$`)
+
+// Problem an inconsistency found in a database
+type Problem struct {
+	Kind    string `json:"kind"`
+	Article string `json:"article"`
+	Message string `json:"message"`
+	// Repaired if it was repaired, in repair mode
+	Repaired bool `json:"repaired"`
+}
+
+func (p *Problem) String() string {
+	s := fmt.Sprintf("%s %q: %s", p.Kind, p.Article, p.Message)
+	if p.Repaired {
+		s += " (repaired)"
+	}
+	return s
+}
+
+// CheckNotebook check the notebook in use, see CheckDatabase
+func CheckNotebook(repair bool) ([]*Problem, error) {
+	return CheckDatabase(db, repair)
+}
+
+// CheckDatabase find orphans, parent cycles, missing fields, duplicate
+// roots, invalid ids and a children index out of step in black. With
+// repair, invalid ids are replaced, missing fields are filled, and
+// orphans, duplicate roots and an article of each cycle are moved under a
+// "Lost and found" article.
+func CheckDatabase(black Storage, repair bool) (problems []*Problem, err error) {
+	check := func(tx Tx) error {
+		problems, err = checkArticles(tx, repair)
+		return err
+	}
+	if repair {
+		err = black.Update(check)
+	} else {
+		err = black.View(check)
+	}
+	return problems, err
+}
+
+// checker collects problems of an article collection in a transaction
+type checker struct {
+	tx       Tx
+	c        Bucket
+	repair   bool
+	problems []*Problem
+	// lostAndFound id of the article orphans are moved under
+	lostAndFound string
+}
+
+// articles run fn on each article, skipping values among them
+func (k *checker) articles(fn func(id []byte, b Bucket) error) error {
+	return k.c.ForEach(func(key, _ []byte) error {
+		if b := k.c.Bucket(key); b != nil {
+			return fn(key, b)
+		}
+		return nil
+	})
+}
+
+func (k *checker) report(kind, id, format string, args ...interface{}) *Problem {
+	p := &Problem{Kind: kind, Article: id, Message: fmt.Sprintf(format, args...),
+		Repaired: k.repair}
+	k.problems = append(k.problems, p)
+	return p
+}
+
+func checkArticles(tx Tx, repair bool) ([]*Problem, error) {
+	c, err := articleCollection(tx)
+	if err != nil {
+		return nil, err
+	}
+	k := &checker{tx: tx, c: c, repair: repair}
+	if c.Bucket([]byte(RootArticleID)) == nil {
+		return nil, ErrArticleNotFound
+	}
+
+	// children index first, before repairs change parents
+	if err := k.checkChildrenIndex(); err != nil {
+		return nil, err
+	}
+	if err := k.checkIDs(); err != nil {
+		return nil, err
+	}
+	if err := k.checkFields(); err != nil {
+		return nil, err
+	}
+	if err := k.checkParents(); err != nil {
+		return nil, err
+	}
+
+	if repair && len(k.problems) > 0 {
+		if err := repairChildrenIndex(tx); err != nil {
+			return nil, err
+		}
+		if err := rebuildIndexes(tx); err != nil {
+			return nil, err
+		}
+	}
+	return k.problems, nil
+}
+
+// repairChildrenIndex rebuild children index, keeping positions of
+// sub-articles which stay under the same parent
+func repairChildrenIndex(tx Tx) error {
+	x := tx.Bucket(childrenCollectionName)
+	if x == nil {
+		return buildChildrenIndex(tx, nil)
+	}
+	order := newMemoryStorage()
+	err := order.Update(func(otx Tx) error {
+		y, err := otx.CreateBucket(childrenCollectionName)
+		if err != nil {
+			return err
+		}
+		return copyBucket(y, x)
+	})
+	if err != nil {
+		return err
+	}
+	return order.View(func(otx Tx) error {
+		return buildChildrenIndex(tx, otx.Bucket(childrenCollectionName))
+	})
+}
+
+// checkIDs find values in article collection and ids which are not valid,
+// articles are given new ids in repair mode
+func (k *checker) checkIDs() error {
+	var values, invalid []string
+	k.c.ForEach(func(key, v []byte) error {
+		if v != nil {
+			values = append(values, string(key))
+		} else if !validIDRegexp.Match(key) {
+			invalid = append(invalid, string(key))
+		}
+		return nil
+	})
+
+	for _, id := range values {
+		k.report(ProblemInvalidID, id, "a value rather than an article")
+		if k.repair {
+			if err := k.c.Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, id := range invalid {
+		p := k.report(ProblemInvalidID, id, "invalid id")
+		if k.repair {
+			to, err := k.rename(id)
+			if err != nil {
+				return err
+			}
+			p.Message += ", changed to " + to
+		}
+	}
+	return nil
+}
+
+// rename give article id a new id, along with its revisions and
+// attachments
+func (k *checker) rename(id string) (string, error) {
+	to, err := newID()
+	if err != nil {
+		return "", err
+	}
+	for _, name := range [][]byte{articleCollectionName,
+		historyCollectionName, attachmentsCollectionName} {
+		x := k.tx.Bucket(name)
+		if x == nil || x.Bucket([]byte(id)) == nil {
+			continue
+		}
+		y, err := x.CreateBucket([]byte(to))
+		if err != nil {
+			return "", err
+		}
+		if err = y.SetSequence(x.Bucket([]byte(id)).Sequence()); err != nil {
+			return "", err
+		}
+		if err = copyBucket(y, x.Bucket([]byte(id))); err != nil {
+			return "", err
+		}
+		if err = x.DeleteBucket([]byte(id)); err != nil {
+			return "", err
+		}
+	}
+
+	// sub-articles follow
+	var subs [][]byte
+	k.articles(func(key []byte, b Bucket) error {
+		if string(b.Get(fParent)) == id {
+			subs = append(subs, append([]byte(nil), key...))
+		}
+		return nil
+	})
+	for _, sub := range subs {
+		if err := k.setParent(k.c.Bucket(sub), to); err != nil {
+			return "", err
+		}
+	}
+	return to, nil
+}
+
+// checkFields find articles missing fields, filled with defaults in repair
+// mode
+func (k *checker) checkFields() error {
+	now := formatTime(time.Now())
+	defaults := []struct {
+		name  []byte
+		value []byte
+	}{
+		{fTitle, []byte("Untitled")},
+		{fContent, []byte{}},
+		{fCreatedAt, now},
+		{fUpdatedAt, now},
+	}
+
+	return k.articles(func(key []byte, b Bucket) error {
+		var missing []string
+		for _, d := range defaults {
+			if b.Get(d.name) != nil {
+				continue
+			}
+			missing = append(missing, string(d.name))
+			if k.repair {
+				if err := b.Put(d.name, d.value); err != nil {
+					return err
+				}
+			}
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+		k.report(ProblemMissingField, string(key), "missing %s",
+			strings.Join(missing, ", "))
+		if k.repair {
+			_, err := bumpVersion(b)
+			return err
+		}
+		return nil
+	})
+}
+
+// checkParents find duplicate roots, orphans and cycles of parents, they
+// are moved under "Lost and found" in repair mode
+func (k *checker) checkParents() error {
+	parents := make(map[string]string)
+	var ids []string
+	k.articles(func(key []byte, b Bucket) error {
+		parents[string(key)] = string(b.Get(fParent))
+		ids = append(ids, string(key))
+		return nil
+	})
+
+	var moves []string
+	for _, id := range ids {
+		parent := parents[id]
+		switch {
+		case id == RootArticleID:
+			if parent != "" {
+				k.report(ProblemOrphan, id, "root article has parent %q", parent)
+				if k.repair {
+					if err := k.setParent(k.c.Bucket([]byte(id)), ""); err != nil {
+						return err
+					}
+				}
+				parents[id] = ""
+			}
+		case parent == "":
+			k.report(ProblemDuplicateRoot, id, "article has no parent")
+			moves = append(moves, id)
+		case parent == id:
+			k.report(ProblemCycle, id, "article is its own parent")
+			moves = append(moves, id)
+		default:
+			if _, ok := parents[parent]; !ok {
+				k.report(ProblemOrphan, id, "parent %q does not exist", parent)
+				moves = append(moves, id)
+			}
+		}
+	}
+	for _, id := range moves {
+		parents[id] = RootArticleID
+	}
+
+	// cycles, each is reported once
+	state := make(map[string]int) // 1 on current path, 2 done
+	for _, id := range ids {
+		var path []string
+		for x := id; x != "" && state[x] == 0; x = parents[x] {
+			state[x] = 1
+			path = append(path, x)
+			next := parents[x]
+			if state[next] != 1 {
+				continue
+			}
+			// next is on path, the cycle starts there
+			var cycle []string
+			for i := len(path) - 1; i >= 0; i-- {
+				cycle = append(cycle, path[i])
+				if path[i] == next {
+					break
+				}
+			}
+			sort.Strings(cycle)
+			k.report(ProblemCycle, cycle[0], "parents form a cycle: %s",
+				strings.Join(cycle, ", "))
+			moves = append(moves, cycle[0])
+			parents[cycle[0]] = RootArticleID
+		}
+		for _, x := range path {
+			state[x] = 2
+		}
+	}
+
+	if !k.repair || len(moves) == 0 {
+		return nil
+	}
+	lost, err := k.lostAndFoundID()
+	if err != nil {
+		return err
+	}
+	for _, id := range moves {
+		if err := k.setParent(k.c.Bucket([]byte(id)), lost); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setParent change parent of article b, as a new version of it
+func (k *checker) setParent(b Bucket, parent string) error {
+	if err := b.Put(fParent, []byte(parent)); err != nil {
+		return err
+	}
+	_, err := bumpVersion(b)
+	return err
+}
+
+// lostAndFoundID return id of the "Lost and found" article under root,
+// which is created if there is none
+func (k *checker) lostAndFoundID() (string, error) {
+	if k.lostAndFound != "" {
+		return k.lostAndFound, nil
+	}
+	var found string
+	k.articles(func(key []byte, b Bucket) error {
+		if found == "" && string(b.Get(fParent)) == RootArticleID &&
+			string(b.Get(fTitle)) == LostAndFoundTitle {
+			found = string(key)
+		}
+		return nil
+	})
+	if found == "" {
+		id, err := newID()
+		if err != nil {
+			return "", err
+		}
+		b, err := k.c.CreateBucket([]byte(id))
+		if err != nil {
+			return "", err
+		}
+		now := formatTime(time.Now())
+		for _, f := range []struct{ name, value []byte }{
+			{fParent, []byte(RootArticleID)},
+			{fTitle, []byte(LostAndFoundTitle)},
+			{fContent, []byte("Articles found without a valid parent.")},
+			{fCreatedAt, now},
+			{fUpdatedAt, now},
+			{fVersion, encodeVersion(1)},
+		} {
+			if err := b.Put(f.name, f.value); err != nil {
+				return "", err
+			}
+		}
+		found = id
+	}
+	k.lostAndFound = found
+	return found, nil
+}
+
+// checkChildrenIndex find entries of children index which do not match
+// parents of articles, the index is rebuilt in repair mode
+func (k *checker) checkChildrenIndex() error {
+	x := k.tx.Bucket(childrenCollectionName)
+	if x == nil {
+		k.report(ProblemChildrenIndex, "", "children index is missing")
+		return nil
+	}
+	indexed := make(map[string]bool)
+	x.ForEach(func(parent, _ []byte) error {
+		p := x.Bucket(parent)
+		if p == nil {
+			return nil
+		}
+		return p.ForEach(func(id, _ []byte) error {
+			indexed[string(id)] = true
+			b := k.c.Bucket(id)
+			if b == nil || string(b.Get(fParent)) != string(parent) {
+				k.report(ProblemChildrenIndex, string(id),
+					"indexed as sub-article of %q", parent)
+			}
+			return nil
+		})
+	})
+	return k.articles(func(id []byte, _ Bucket) error {
+		if !indexed[string(id)] && string(id) != RootArticleID {
+			k.report(ProblemChildrenIndex, string(id),
+				"missing in children index")
+		}
+		return nil
+	})
+}
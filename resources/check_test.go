@@ -0,0 +1,82 @@
+package resources
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCheckNotebook(t *testing.T) {
+	openTestDatabase(t)
+	a := createArticle(t, RootArticleID, "a")
+	b := createArticle(t, RootArticleID, "b")
+	c := createArticle(t, RootArticleID, "c")
+	if problems, err := CheckNotebook(false); err != nil || len(problems) != 0 {
+		t.Fatalf("problems of a new notebook = %v, %v", problems, err)
+	}
+
+	err := db.Update(func(tx Tx) error {
+		articles, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		articles.Bucket([]byte(a.ID)).Delete(fTitle)
+		articles.Bucket([]byte(b.ID)).Put(fParent, []byte("missing"))
+		articles.Bucket([]byte(c.ID)).Put(fParent, []byte(c.ID))
+		articles.Put([]byte("junk"), []byte("v"))
+		bad, err := articles.CreateBucket([]byte("bad id!"))
+		if err != nil {
+			return err
+		}
+		bad.Put(fParent, []byte(RootArticleID))
+		bad.Put(fTitle, []byte("bad"))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := CheckNotebook(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := problemKinds(problems), []string{
+		ProblemChildrenIndex, ProblemChildrenIndex, ProblemChildrenIndex,
+		ProblemCycle, ProblemInvalidID, ProblemInvalidID, ProblemMissingField,
+		ProblemMissingField, ProblemOrphan,
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("problems = %v, want kinds %v", problems, want)
+	}
+
+	if _, err := CheckNotebook(true); err != nil {
+		t.Fatal(err)
+	}
+	if problems, err := CheckNotebook(false); err != nil || len(problems) != 0 {
+		t.Fatalf("problems after repair = %v, %v", problems, err)
+	}
+	if got, err := GetArticle(a.ID); err != nil || got.Title != "Untitled" {
+		t.Errorf("article without title = %+v, %v", got, err)
+	}
+
+	// moved articles are in any order
+	lost, _ := GetArticle(b.ID)
+	got := subTitles(t, lost.Parent)
+	sort.Strings(got)
+	if want := []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sub-articles of %s = %v, want %v", LostAndFoundTitle, got, want)
+	}
+	got = subTitles(t, RootArticleID)
+	sort.Strings(got)
+	if want := []string{LostAndFoundTitle, "Untitled", "bad"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sub-articles of root = %v, want %v", got, want)
+	}
+}
+
+func problemKinds(problems []*Problem) []string {
+	kinds := []string{}
+	for _, p := range problems {
+		kinds = append(kinds, p.Kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
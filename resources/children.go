@@ -0,0 +1,263 @@
+package resources
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// childrenCollectionName names the index from parent id to ids of its
+// sub-articles, each id maps to its position among siblings
+var childrenCollectionName = []byte("Children")
+
+// sort orders of sub-articles, prefix "-" to reverse one
+const (
+	SortByPosition  = ""
+	SortByTitle     = "title"
+	SortByCreatedAt = "created"
+	SortByUpdatedAt = "updated"
+)
+
+// ErrUnknownSortOrder returned for unsupported sort order
+var ErrUnknownSortOrder = errors.New("unknown sort order")
+
+// MoveToIndex move article to index among its siblings
+func (a *Article) MoveToIndex(index int) error {
+	return a.reorder(func(int) int { return index })
+}
+
+// MoveBy move article up (negative delta) or down among its siblings
+func (a *Article) MoveBy(delta int) error {
+	return a.reorder(func(current int) int { return current + delta })
+}
+
+func (a *Article) reorder(target func(current int) int) error {
+	return db.Update(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		b := c.Bucket([]byte(a.ID))
+		if b == nil {
+			return ErrArticleNotFound
+		}
+		parent := string(b.Get(fParent))
+		if parent == "" {
+			return errors.New("root article has no siblings")
+		}
+
+		siblings := childrenOf(tx, parent)
+		current := -1
+		for i, id := range siblings {
+			if id == a.ID {
+				current = i
+				break
+			}
+		}
+		if current == -1 {
+			return ErrArticleNotFound
+		}
+
+		index := target(current)
+		if index < 0 {
+			index = 0
+		}
+		if index > len(siblings)-1 {
+			index = len(siblings) - 1
+		}
+		siblings = append(siblings[:current], siblings[current+1:]...)
+		siblings = append(siblings[:index],
+			append([]string{a.ID}, siblings[index:]...)...)
+
+		return setChildren(tx, parent, siblings)
+	})
+}
+
+// SortArticleTitles sort articles in specified order,
+// SortByPosition keeps the order as it is
+func SortArticleTitles(subs []*ArticleTitle, order string) error {
+	if order == SortByPosition {
+		return nil
+	}
+	less, err := articleTitleLess(order)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(subs, func(i, j int) bool {
+		return less(subs[i], subs[j])
+	})
+	return nil
+}
+
+// articleTitleLess return the comparison of an order
+func articleTitleLess(order string) (func(x, y *ArticleTitle) bool, error) {
+	desc := strings.HasPrefix(order, "-")
+	var less func(x, y *ArticleTitle) bool
+	switch strings.TrimPrefix(order, "-") {
+	case SortByTitle:
+		less = func(x, y *ArticleTitle) bool {
+			return strings.ToLower(x.Title) < strings.ToLower(y.Title)
+		}
+	case SortByCreatedAt:
+		less = func(x, y *ArticleTitle) bool {
+			return x.CreatedAt.Before(y.CreatedAt)
+		}
+	case SortByUpdatedAt:
+		less = func(x, y *ArticleTitle) bool {
+			return x.UpdatedAt.Before(y.UpdatedAt)
+		}
+	default:
+		return nil, ErrUnknownSortOrder
+	}
+
+	if desc {
+		return func(x, y *ArticleTitle) bool { return less(y, x) }, nil
+	}
+	return less, nil
+}
+
+// addChild record child as the last sub-article of parent in children index
+func addChild(tx Tx, parent, child string) error {
+	if parent == "" {
+		return nil
+	}
+	c, err := tx.CreateBucketIfNotExists(childrenCollectionName)
+	if err != nil {
+		return err
+	}
+	p, err := c.CreateBucketIfNotExists([]byte(parent))
+	if err != nil {
+		return err
+	}
+
+	var last uint64
+	p.ForEach(func(_, v []byte) error {
+		if pos := decodePosition(v); pos > last {
+			last = pos
+		}
+		return nil
+	})
+	return p.Put([]byte(child), encodePosition(last+1))
+}
+
+// removeChild remove child from parent in children index
+func removeChild(tx Tx, parent, child string) error {
+	c := tx.Bucket(childrenCollectionName)
+	if c == nil || parent == "" {
+		return nil
+	}
+	p := c.Bucket([]byte(parent))
+	if p == nil {
+		return nil
+	}
+	if err := p.Delete([]byte(child)); err != nil {
+		return err
+	}
+
+	// drop empty entry
+	if k, _ := p.Cursor().First(); k == nil {
+		return c.DeleteBucket([]byte(parent))
+	}
+	return nil
+}
+
+// childrenOf return ids of sub-articles of parent, in position order
+func childrenOf(tx Tx, parent string) []string {
+	c := tx.Bucket(childrenCollectionName)
+	if c == nil {
+		return nil
+	}
+	p := c.Bucket([]byte(parent))
+	if p == nil {
+		return nil
+	}
+	return orderedChildren(p)
+}
+
+// orderedChildren return ids in an entry of children index, in position order
+func orderedChildren(p Bucket) []string {
+	type child struct {
+		id  string
+		pos uint64
+	}
+	var children []child
+	p.ForEach(func(k, v []byte) error {
+		children = append(children, child{string(k), decodePosition(v)})
+		return nil
+	})
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].pos < children[j].pos
+	})
+
+	ids := make([]string, len(children))
+	for i, v := range children {
+		ids[i] = v.id
+	}
+	return ids
+}
+
+// setChildren renumber sub-articles of parent in order of ids
+func setChildren(tx Tx, parent string, ids []string) error {
+	c, err := tx.CreateBucketIfNotExists(childrenCollectionName)
+	if err != nil {
+		return err
+	}
+	p, err := c.CreateBucketIfNotExists([]byte(parent))
+	if err != nil {
+		return err
+	}
+	for i, id := range ids {
+		if err := p.Put([]byte(id), encodePosition(uint64(i+1))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildChildrenIndex (re)build children index from article collection,
+// positions are taken from order if it is not nil
+func buildChildrenIndex(tx Tx, order Bucket) error {
+	if tx.Bucket(childrenCollectionName) != nil {
+		if err := tx.DeleteBucket(childrenCollectionName); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.CreateBucket(childrenCollectionName); err != nil {
+		return err
+	}
+
+	c, err := articleCollection(tx)
+	if err != nil {
+		return err
+	}
+	return c.ForEach(func(k, _ []byte) error {
+		parent := c.Bucket(k).Get(fParent)
+		if err := addChild(tx, string(parent), string(k)); err != nil {
+			return err
+		}
+		if order == nil || len(parent) == 0 {
+			return nil
+		}
+		if o := order.Bucket(parent); o != nil {
+			if v := o.Get(k); len(v) == 8 {
+				p := tx.Bucket(childrenCollectionName).Bucket(parent)
+				return p.Put(k, v)
+			}
+		}
+		return nil
+	})
+}
+
+func encodePosition(pos uint64) []byte {
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, pos)
+	return v
+}
+
+func decodePosition(v []byte) uint64 {
+	if len(v) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(v)
+}
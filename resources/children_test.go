@@ -0,0 +1,22 @@
+package resources
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMoveToIndex(t *testing.T) {
+	openTestDatabase(t)
+	a := createArticle(t, RootArticleID, "a")
+	for _, title := range []string{"x", "y", "z"} {
+		createArticle(t, a.ID, title)
+	}
+	subs, _ := a.GetSubArticles("")
+	z := &Article{ID: subs[2].ID}
+	if err := z.MoveToIndex(0); err != nil {
+		t.Fatal(err)
+	}
+	if got := subTitles(t, a.ID); !reflect.DeepEqual(got, []string{"z", "x", "y"}) {
+		t.Errorf("after MoveToIndex = %v", got)
+	}
+}
@@ -0,0 +1,102 @@
+package resources
+
+import (
+	"errors"
+	"time"
+)
+
+// CopyTo deep copy article and all its descendants under parent,
+// returns id of the copy of article
+func (a *Article) CopyTo(parent string) (copyID string, err error) {
+	err = db.Update(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		if c.Bucket([]byte(a.ID)) == nil {
+			return ErrArticleNotFound
+		}
+		if c.Bucket([]byte(parent)) == nil {
+			return ErrArticleNotFound
+		}
+
+		// collect subtree first, parent may be inside it
+		ids := []string{a.ID}
+		subsOf := make(map[string][]string)
+		for i := 0; i < len(ids); i++ {
+			subsOf[ids[i]] = childrenOf(tx, ids[i])
+			ids = append(ids, subsOf[ids[i]]...)
+		}
+
+		// ids of copies
+		copies := make(map[string]string, len(ids))
+		for _, id := range ids {
+			if copies[id], err = newID(); err != nil {
+				return err
+			}
+			if c.Bucket([]byte(copies[id])) != nil {
+				return errors.New("article already exists")
+			}
+		}
+
+		now := time.Now()
+		for _, id := range ids {
+			p := copies[string(c.Bucket([]byte(id)).Get(fParent))]
+			if id == a.ID {
+				p = parent
+			}
+			dst, err := copyArticle(tx, c, id, copies[id], p, now)
+			if err != nil {
+				return err
+			}
+			if err = indexArticle(tx, []byte(copies[id]), dst); err != nil {
+				return err
+			}
+		}
+
+		// children index, sub-articles keep their order
+		if err = addChild(tx, parent, copies[a.ID]); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if len(subsOf[id]) == 0 {
+				continue
+			}
+			subs := make([]string, len(subsOf[id]))
+			for i, sub := range subsOf[id] {
+				subs[i] = copies[sub]
+			}
+			if err = setChildren(tx, copies[id], subs); err != nil {
+				return err
+			}
+		}
+
+		copyID = copies[a.ID]
+		return nil
+	})
+	return
+}
+
+// copyArticle copy article id as copyID under parent, along with its
+// attachments, as a new article created at now. The copy is not indexed.
+func copyArticle(tx Tx, c Bucket, id, copyID, parent string,
+	now time.Time) (Bucket, error) {
+	dst, err := c.CreateBucket([]byte(copyID))
+	if err != nil {
+		return nil, err
+	}
+	if err = copyBucket(dst, c.Bucket([]byte(id))); err != nil {
+		return nil, err
+	}
+	for _, f := range []struct{ name, value []byte }{
+		{fParent, []byte(parent)},
+		{fCreatedAt, formatTime(now)},
+		{fUpdatedAt, formatTime(now)},
+		{fVersion, encodeVersion(1)},
+	} {
+		if err = dst.Put(f.name, f.value); err != nil {
+			return nil, err
+		}
+	}
+	return dst, copyAttachments(tx, id, copyID)
+}
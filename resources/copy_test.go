@@ -0,0 +1,31 @@
+package resources
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCopyTo(t *testing.T) {
+	openTestDatabase(t)
+	a := createArticle(t, RootArticleID, "a")
+	var z *Article
+	for _, title := range []string{"x", "y", "z"} {
+		z = createArticle(t, a.ID, title)
+	}
+
+	// copy into its own subtree
+	id, err := a.CopyTo(z.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := subTitles(t, id); !reflect.DeepEqual(got, []string{"x", "y", "z"}) {
+		t.Errorf("sub-articles of copy = %v", got)
+	}
+	if got := subTitles(t, z.ID); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("sub-articles of z = %v, want the copy", got)
+	}
+	c, err := GetArticle(id)
+	if err != nil || c.Version != 1 || c.Parent != z.ID {
+		t.Errorf("copy = %+v, %v", c, err)
+	}
+}
@@ -0,0 +1,602 @@
+package resources
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// meta field names of encryption, they are kept in plain text
+var (
+	fEncryptionSalt       = []byte("EncryptionSalt")
+	fEncryptionIterations = []byte("EncryptionIterations")
+	fEncryptionCheck      = []byte("EncryptionCheck")
+)
+
+const (
+	encryptionIterations = 200000
+	encryptionSaltSize   = 16
+	encryptionKeySize    = 32
+)
+
+// encryptionCheck sealed with the key and kept in meta, to tell whether a
+// passphrase is right
+var encryptionCheck = []byte("notes encryption check")
+
+// encryption errors
+var (
+	ErrLocked            = errors.New("database is encrypted and locked, unlock it with the passphrase")
+	ErrWrongPassphrase   = errors.New("wrong passphrase")
+	ErrEmptyPassphrase   = errors.New("empty passphrase")
+	ErrAlreadyEncrypted  = errors.New("database is already encrypted")
+	ErrDecryptionFailure = errors.New("stored data can not be decrypted, it is damaged or was tampered with")
+)
+
+// cryptStorage encrypts values of all buckets but meta with AES-GCM, using
+// a key derived from a passphrase. Buckets and keys stay in plain text, so
+// that ids remain readable. Secondary indexes hold words and titles taken
+// from articles, so for an encrypted database they are kept in memory
+// rather than in raw, and built when it is unlocked.
+// A database without encryption passes straight through.
+type cryptStorage struct {
+	mu        sync.RWMutex
+	raw       Storage
+	encrypted bool
+	aead      cipher.AEAD // nil while locked
+	index     *memoryStorage
+}
+
+func newCryptStorage(raw Storage) (*cryptStorage, error) {
+	s := &cryptStorage{raw: raw}
+	err := raw.View(func(tx Tx) error {
+		meta := tx.Bucket(metaCollectionName)
+		s.encrypted = meta != nil && meta.Get(fEncryptionSalt) != nil
+		return nil
+	})
+	return s, err
+}
+
+func (s *cryptStorage) View(fn func(Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.encrypted {
+		return s.raw.View(fn)
+	}
+	if s.aead == nil {
+		return ErrLocked
+	}
+	return s.index.View(func(itx Tx) error {
+		return s.raw.View(func(tx Tx) (err error) {
+			defer recoverDecryption(&err)
+			return fn(&cryptTx{tx: tx, index: itx, aead: s.aead})
+		})
+	})
+}
+
+func (s *cryptStorage) Update(fn func(Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.update(fn)
+}
+
+// update run fn in a read-write transaction, mu must be held
+func (s *cryptStorage) update(fn func(Tx) error) error {
+	if !s.encrypted {
+		return s.raw.Update(fn)
+	}
+	if s.aead == nil {
+		return ErrLocked
+	}
+	// indexes are committed only if raw is, an error from raw or fn
+	// discards both
+	return s.index.Update(func(itx Tx) error {
+		return s.raw.Update(func(tx Tx) (err error) {
+			defer recoverDecryption(&err)
+			return fn(&cryptTx{tx: tx, index: itx, aead: s.aead})
+		})
+	})
+}
+
+// decryptionFailure panic of cryptBucket.open, as Get and cursors have no
+// way to report an error, see recoverDecryption
+type decryptionFailure struct{}
+
+// recoverDecryption turn a decryptionFailure into ErrDecryptionFailure,
+// which aborts the transaction, other panics go on
+func recoverDecryption(err *error) {
+	if r := recover(); r != nil {
+		if _, ok := r.(decryptionFailure); !ok {
+			panic(r)
+		}
+		*err = ErrDecryptionFailure
+	}
+}
+
+// WriteTo write data as stored, an encrypted database stays encrypted
+func (s *cryptStorage) WriteTo(w io.Writer) (int64, error) {
+	return s.raw.WriteTo(w)
+}
+
+func (s *cryptStorage) Close() error {
+	return s.raw.Close()
+}
+
+// unlock derive key from passphrase and check it, indexes start empty
+func (s *cryptStorage) unlock(passphrase string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.encrypted || s.aead != nil {
+		return nil
+	}
+
+	var salt, check []byte
+	var iterations int
+	err := s.raw.View(func(tx Tx) error {
+		meta := tx.Bucket(metaCollectionName)
+		salt = append(salt, meta.Get(fEncryptionSalt)...)
+		check = append(check, meta.Get(fEncryptionCheck)...)
+		var err error
+		iterations, err = strconv.Atoi(string(meta.Get(fEncryptionIterations)))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	aead, err := newEncryptionAEAD(passphrase, salt, iterations)
+	if err != nil {
+		return err
+	}
+	if _, err := openValue(aead, fEncryptionCheck, check); err != nil {
+		return ErrWrongPassphrase
+	}
+	s.aead = aead
+	s.index = newMemoryStorage()
+	return nil
+}
+
+// enable encrypt all values in raw with a key derived from passphrase, and
+// move secondary indexes into memory
+func (s *cryptStorage) enable(passphrase string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.encrypted {
+		return ErrAlreadyEncrypted
+	}
+	if passphrase == "" {
+		return ErrEmptyPassphrase
+	}
+
+	salt := make([]byte, encryptionSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	aead, err := newEncryptionAEAD(passphrase, salt, encryptionIterations)
+	if err != nil {
+		return err
+	}
+
+	err = s.raw.Update(func(tx Tx) error {
+		for _, name := range secondaryIndexes {
+			if tx.Bucket(name) != nil {
+				if err := tx.DeleteBucket(name); err != nil {
+					return err
+				}
+			}
+		}
+
+		var names [][]byte
+		err := tx.ForEach(func(name []byte) error {
+			if !bytes.Equal(name, metaCollectionName) {
+				names = append(names, append([]byte(nil), name...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			b := tx.Bucket(name)
+			err := encryptBucket(b, newCryptBucket(b, aead, nil, name))
+			if err != nil {
+				return err
+			}
+		}
+
+		meta, err := tx.CreateBucketIfNotExists(metaCollectionName)
+		if err != nil {
+			return err
+		}
+		check, err := sealValue(aead, fEncryptionCheck, encryptionCheck)
+		if err != nil {
+			return err
+		}
+		if err = meta.Put(fEncryptionCheck, check); err != nil {
+			return err
+		}
+		err = meta.Put(fEncryptionIterations,
+			[]byte(strconv.Itoa(encryptionIterations)))
+		if err != nil {
+			return err
+		}
+		return meta.Put(fEncryptionSalt, salt)
+	})
+	if err != nil {
+		return err
+	}
+	// plain text is left in pages freed by bolt
+	if b, ok := s.raw.(*boltStorage); ok {
+		if err := b.compact(); err != nil {
+			return err
+		}
+	}
+
+	s.encrypted, s.aead, s.index = true, aead, newMemoryStorage()
+	return s.update(rebuildIndexes)
+}
+
+// encryptBucket replace plain values of raw bucket b with ones sealed by
+// c, which wraps b
+func encryptBucket(b Bucket, c *cryptBucket) error {
+	var keys, values, subs [][]byte
+	err := b.ForEach(func(k, v []byte) error {
+		k = append([]byte(nil), k...)
+		if v == nil {
+			subs = append(subs, k)
+			return nil
+		}
+		keys = append(keys, k)
+		values = append(values, append([]byte(nil), v...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, k := range keys {
+		if err := c.Put(k, values[i]); err != nil {
+			return err
+		}
+	}
+	for _, k := range subs {
+		if err := encryptBucket(b.Bucket(k), c.Bucket(k).(*cryptBucket)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// state tell whether s is encrypted, and whether it is locked
+func (s *cryptStorage) state() (encrypted, locked bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.encrypted, s.encrypted && s.aead == nil
+}
+
+// IsEncrypted tell whether the database is encrypted
+func IsEncrypted() bool {
+	encrypted, _ := db.(*cryptStorage).state()
+	return encrypted
+}
+
+// IsLocked tell whether the database is encrypted and not yet unlocked
+func IsLocked() bool {
+	_, locked := db.(*cryptStorage).state()
+	return locked
+}
+
+// Unlock unlock an encrypted database with passphrase, then upgrade it and
+// build its indexes. Nothing is done for a database without encryption.
+func Unlock(passphrase string) error {
+	return unlock(db.(*cryptStorage), passphrase)
+}
+
+// unlock unlock s with passphrase if it is locked, ErrLocked is returned
+// for an empty passphrase
+func unlock(s *cryptStorage, passphrase string) error {
+	if _, locked := s.state(); !locked {
+		return nil
+	}
+	if passphrase == "" {
+		return ErrLocked
+	}
+	if err := s.unlock(passphrase); err != nil {
+		return err
+	}
+	if err := MigrateDatabase(s); err != nil {
+		return err
+	}
+	return s.Update(rebuildIndexes)
+}
+
+// EnableEncryption encrypt the database with a key derived from passphrase.
+// The passphrase is needed to unlock it whenever the program starts, and to
+// restore backups exported from then on. It can not be recovered if lost.
+func EnableEncryption(passphrase string) error {
+	return db.(*cryptStorage).enable(passphrase)
+}
+
+// OpenBackup open a database file exported before, to check and restore
+// it. passphrase unlocks a backup of an encrypted database.
+func OpenBackup(file, passphrase string) (Storage, error) {
+	raw, err := openBoltStorage(file)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newCryptStorage(raw)
+	if err != nil {
+		raw.Close()
+		return nil, err
+	}
+	if s.encrypted {
+		if passphrase == "" {
+			raw.Close()
+			return nil, errors.New("backup is encrypted, its passphrase is required")
+		}
+		if err := s.unlock(passphrase); err != nil {
+			raw.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func newEncryptionAEAD(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, ErrEmptyPassphrase
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations,
+		encryptionKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealValue encrypt v as nonce followed by cipher text, ad binds it to
+// where it is stored
+func sealValue(aead cipher.AEAD, ad, v []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(v)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, v, ad), nil
+}
+
+// openValue decrypt v sealed by sealValue
+func openValue(aead cipher.AEAD, ad, v []byte) ([]byte, error) {
+	if len(v) < aead.NonceSize() {
+		return nil, ErrDecryptionFailure
+	}
+	n := aead.NonceSize()
+	p, err := aead.Open(nil, v[:n], v[n:], ad)
+	if err != nil {
+		return nil, ErrDecryptionFailure
+	}
+	return p, nil
+}
+
+// appendPathElem append name to a bucket path, prefixed by its length so
+// that different paths never encode the same
+func appendPathElem(path, name []byte) []byte {
+	path = binary.AppendUvarint(path[:len(path):len(path)], uint64(len(name)))
+	return append(path, name...)
+}
+
+// cryptTx a transaction of an unlocked cryptStorage
+type cryptTx struct {
+	tx, index Tx
+	aead      cipher.AEAD
+}
+
+func (t *cryptTx) ID() int {
+	return t.tx.ID()
+}
+
+// backend return transaction holding top level bucket name
+func (t *cryptTx) backend(name []byte) Tx {
+	if isSecondaryIndex(name) {
+		return t.index
+	}
+	return t.tx
+}
+
+// wrap return bucket name of raw transaction as seen through encryption
+func (t *cryptTx) wrap(name []byte, b Bucket) Bucket {
+	if b == nil || isSecondaryIndex(name) ||
+		bytes.Equal(name, metaCollectionName) {
+		return b
+	}
+	return newCryptBucket(b, t.aead, nil, name)
+}
+
+func (t *cryptTx) Bucket(name []byte) Bucket {
+	return t.wrap(name, t.backend(name).Bucket(name))
+}
+
+func (t *cryptTx) CreateBucket(name []byte) (Bucket, error) {
+	b, err := t.backend(name).CreateBucket(name)
+	return t.wrap(name, b), err
+}
+
+func (t *cryptTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	b, err := t.backend(name).CreateBucketIfNotExists(name)
+	return t.wrap(name, b), err
+}
+
+func (t *cryptTx) DeleteBucket(name []byte) error {
+	return t.backend(name).DeleteBucket(name)
+}
+
+func (t *cryptTx) ForEach(fn func(name []byte) error) error {
+	var names [][]byte
+	collect := func(name []byte) error {
+		names = append(names, append([]byte(nil), name...))
+		return nil
+	}
+	if err := t.tx.ForEach(collect); err != nil {
+		return err
+	}
+	if err := t.index.ForEach(collect); err != nil {
+		return err
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return bytes.Compare(names[i], names[j]) < 0
+	})
+	for _, name := range names {
+		if err := fn(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isSecondaryIndex(name []byte) bool {
+	for _, x := range secondaryIndexes {
+		if bytes.Equal(name, x) {
+			return true
+		}
+	}
+	return false
+}
+
+// cryptBucket a bucket whose values are sealed, each with the path of the
+// bucket and its key as additional data, so that values can not be moved
+// around without being noticed
+type cryptBucket struct {
+	b    Bucket
+	aead cipher.AEAD
+	path []byte
+}
+
+func newCryptBucket(b Bucket, aead cipher.AEAD, parent, name []byte) *cryptBucket {
+	return &cryptBucket{b: b, aead: aead, path: appendPathElem(parent, name)}
+}
+
+func (b *cryptBucket) ad(key []byte) []byte {
+	return appendPathElem(b.path, key)
+}
+
+// open decrypt value v of key, nil stays nil. A value which can not be
+// decrypted aborts the transaction with ErrDecryptionFailure, rather than
+// reading as missing and being taken for a value never set.
+func (b *cryptBucket) open(key, v []byte) []byte {
+	if v == nil {
+		return nil
+	}
+	p, err := openValue(b.aead, b.ad(key), v)
+	if err != nil {
+		panic(decryptionFailure{})
+	}
+	if p == nil {
+		p = []byte{}
+	}
+	return p
+}
+
+func (b *cryptBucket) Get(key []byte) []byte {
+	return b.open(key, b.b.Get(key))
+}
+
+func (b *cryptBucket) Put(key, value []byte) error {
+	if len(key) == 0 {
+		return ErrKeyRequired
+	}
+	v, err := sealValue(b.aead, b.ad(key), value)
+	if err != nil {
+		return err
+	}
+	return b.b.Put(key, v)
+}
+
+func (b *cryptBucket) Delete(key []byte) error {
+	return b.b.Delete(key)
+}
+
+func (b *cryptBucket) wrap(name []byte, c Bucket) Bucket {
+	if c == nil {
+		return nil
+	}
+	return newCryptBucket(c, b.aead, b.path, name)
+}
+
+func (b *cryptBucket) Bucket(name []byte) Bucket {
+	return b.wrap(name, b.b.Bucket(name))
+}
+
+func (b *cryptBucket) CreateBucket(name []byte) (Bucket, error) {
+	c, err := b.b.CreateBucket(name)
+	return b.wrap(name, c), err
+}
+
+func (b *cryptBucket) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	c, err := b.b.CreateBucketIfNotExists(name)
+	return b.wrap(name, c), err
+}
+
+func (b *cryptBucket) DeleteBucket(name []byte) error {
+	return b.b.DeleteBucket(name)
+}
+
+func (b *cryptBucket) ForEach(fn func(k, v []byte) error) error {
+	return b.b.ForEach(func(k, v []byte) error {
+		return fn(k, b.open(k, v))
+	})
+}
+
+func (b *cryptBucket) Cursor() Cursor {
+	return cryptCursor{c: b.b.Cursor(), b: b}
+}
+
+func (b *cryptBucket) Sequence() uint64 {
+	return b.b.Sequence()
+}
+
+func (b *cryptBucket) SetSequence(v uint64) error {
+	return b.b.SetSequence(v)
+}
+
+func (b *cryptBucket) NextSequence() (uint64, error) {
+	return b.b.NextSequence()
+}
+
+type cryptCursor struct {
+	c Cursor
+	b *cryptBucket
+}
+
+func (c cryptCursor) open(k, v []byte) ([]byte, []byte) {
+	return k, c.b.open(k, v)
+}
+
+func (c cryptCursor) First() ([]byte, []byte) {
+	return c.open(c.c.First())
+}
+
+func (c cryptCursor) Last() ([]byte, []byte) {
+	return c.open(c.c.Last())
+}
+
+func (c cryptCursor) Next() ([]byte, []byte) {
+	return c.open(c.c.Next())
+}
+
+func (c cryptCursor) Prev() ([]byte, []byte) {
+	return c.open(c.c.Prev())
+}
+
+func (c cryptCursor) Seek(seek []byte) ([]byte, []byte) {
+	return c.open(c.c.Seek(seek))
+}
@@ -0,0 +1,116 @@
+package resources
+
+import (
+	"bytes"
+	"testing"
+)
+
+var testBucket = []byte("test")
+
+// newEncryptedStorage a memory storage encrypted with passphrase, holding
+// value v of key k in bucket testBucket
+func newEncryptedStorage(t *testing.T, k, v []byte) (*cryptStorage, *memoryStorage) {
+	raw := newMemoryStorage()
+	err := raw.Update(func(tx Tx) error {
+		if _, err := tx.CreateBucket(articleCollectionName); err != nil {
+			return err
+		}
+		c, err := tx.CreateBucket(testBucket)
+		if err != nil {
+			return err
+		}
+		return c.Put(k, v)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := newCryptStorage(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.enable("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	return s, raw
+}
+
+func TestCryptStorage(t *testing.T) {
+	k, v := []byte("key"), []byte("value")
+	s, raw := newEncryptedStorage(t, k, v)
+
+	raw.View(func(tx Tx) error {
+		if got := tx.Bucket(testBucket).Get(k); bytes.Contains(got, v) {
+			t.Errorf("value is stored in plain text: %q", got)
+		}
+		return nil
+	})
+	s.View(func(tx Tx) error {
+		if got := tx.Bucket(testBucket).Get(k); !bytes.Equal(got, v) {
+			t.Errorf("Get = %q, want %q", got, v)
+		}
+		return nil
+	})
+
+	// locked again, only the right passphrase unlocks it
+	s2, err := newCryptStorage(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.View(func(Tx) error { return nil }); err != ErrLocked {
+		t.Errorf("View of a locked storage: %v, want ErrLocked", err)
+	}
+	if err := s2.unlock("wrong"); err != ErrWrongPassphrase {
+		t.Errorf("unlock with a wrong passphrase: %v", err)
+	}
+	if err := s2.unlock("passphrase"); err != nil {
+		t.Errorf("unlock: %v", err)
+	}
+}
+
+func TestCryptStorageTampered(t *testing.T) {
+	k := []byte("key")
+	s, raw := newEncryptedStorage(t, k, []byte("value"))
+	err := raw.Update(func(tx Tx) error {
+		c := tx.Bucket(testBucket)
+		v := append([]byte(nil), c.Get(k)...)
+		v[len(v)-1] ^= 1
+		return c.Put(k, v)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.View(func(tx Tx) error {
+		tx.Bucket(testBucket).Get(k)
+		return nil
+	})
+	if err != ErrDecryptionFailure {
+		t.Errorf("Get of a tampered value: %v, want ErrDecryptionFailure", err)
+	}
+	err = s.View(func(tx Tx) error {
+		tx.Bucket(testBucket).Cursor().First()
+		return nil
+	})
+	if err != ErrDecryptionFailure {
+		t.Errorf("Cursor of a tampered value: %v, want ErrDecryptionFailure", err)
+	}
+
+	// the transaction is aborted, so is what it wrote
+	err = s.Update(func(tx Tx) error {
+		c := tx.Bucket(testBucket)
+		if err := c.Put([]byte("other"), []byte("x")); err != nil {
+			return err
+		}
+		c.Get(k)
+		return nil
+	})
+	if err != ErrDecryptionFailure {
+		t.Errorf("Update reading a tampered value: %v, want ErrDecryptionFailure", err)
+	}
+	raw.View(func(tx Tx) error {
+		if tx.Bucket(testBucket).Get([]byte("other")) != nil {
+			t.Error("aborted transaction was committed")
+		}
+		return nil
+	})
+}
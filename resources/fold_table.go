@@ -0,0 +1,358 @@
+package resources
+
+// foldTable maps letters and digits to their compatibility decompositions
+// (NFKC), case folded and without accents. Runes which only need
+// unicode.ToLower are not listed.
+//
+// The table is maintained by hand, from Unicode 14.0.0 character data. It
+// covers Latin, Greek and Cyrillic letters with accents (U+00AA-U+04FF,
+// U+1E00-U+1FFF), superscripts and subscripts, letterlike symbols, number
+// forms, enclosed alphanumerics, CJK compatibility ideographs, alphabetic
+// presentation forms, and halfwidth and fullwidth forms. Other compatibility
+// characters are not normalized.
+var foldTable = map[rune]string{
+	0x00AA: "a", 0x00B2: "2", 0x00B3: "3", 0x00B5: "μ", 0x00B9: "1",
+	0x00BA: "o", 0x00BC: "1⁄4", 0x00BD: "1⁄2", 0x00BE: "3⁄4", 0x00C0: "a",
+	0x00C1: "a", 0x00C2: "a", 0x00C3: "a", 0x00C4: "a", 0x00C5: "a",
+	0x00C7: "c", 0x00C8: "e", 0x00C9: "e", 0x00CA: "e", 0x00CB: "e",
+	0x00CC: "i", 0x00CD: "i", 0x00CE: "i", 0x00CF: "i", 0x00D1: "n",
+	0x00D2: "o", 0x00D3: "o", 0x00D4: "o", 0x00D5: "o", 0x00D6: "o",
+	0x00D9: "u", 0x00DA: "u", 0x00DB: "u", 0x00DC: "u", 0x00DD: "y",
+	0x00DF: "ss", 0x00E0: "a", 0x00E1: "a", 0x00E2: "a", 0x00E3: "a",
+	0x00E4: "a", 0x00E5: "a", 0x00E7: "c", 0x00E8: "e", 0x00E9: "e",
+	0x00EA: "e", 0x00EB: "e", 0x00EC: "i", 0x00ED: "i", 0x00EE: "i",
+	0x00EF: "i", 0x00F1: "n", 0x00F2: "o", 0x00F3: "o", 0x00F4: "o",
+	0x00F5: "o", 0x00F6: "o", 0x00F9: "u", 0x00FA: "u", 0x00FB: "u",
+	0x00FC: "u", 0x00FD: "y", 0x00FF: "y", 0x0100: "a", 0x0101: "a",
+	0x0102: "a", 0x0103: "a", 0x0104: "a", 0x0105: "a", 0x0106: "c",
+	0x0107: "c", 0x0108: "c", 0x0109: "c", 0x010A: "c", 0x010B: "c",
+	0x010C: "c", 0x010D: "c", 0x010E: "d", 0x010F: "d", 0x0112: "e",
+	0x0113: "e", 0x0114: "e", 0x0115: "e", 0x0116: "e", 0x0117: "e",
+	0x0118: "e", 0x0119: "e", 0x011A: "e", 0x011B: "e", 0x011C: "g",
+	0x011D: "g", 0x011E: "g", 0x011F: "g", 0x0120: "g", 0x0121: "g",
+	0x0122: "g", 0x0123: "g", 0x0124: "h", 0x0125: "h", 0x0128: "i",
+	0x0129: "i", 0x012A: "i", 0x012B: "i", 0x012C: "i", 0x012D: "i",
+	0x012E: "i", 0x012F: "i", 0x0130: "i", 0x0132: "ij", 0x0133: "ij",
+	0x0134: "j", 0x0135: "j", 0x0136: "k", 0x0137: "k", 0x0139: "l",
+	0x013A: "l", 0x013B: "l", 0x013C: "l", 0x013D: "l", 0x013E: "l",
+	0x013F: "l·", 0x0140: "l·", 0x0143: "n", 0x0144: "n", 0x0145: "n",
+	0x0146: "n", 0x0147: "n", 0x0148: "n", 0x0149: "ʼn", 0x014C: "o",
+	0x014D: "o", 0x014E: "o", 0x014F: "o", 0x0150: "o", 0x0151: "o",
+	0x0154: "r", 0x0155: "r", 0x0156: "r", 0x0157: "r", 0x0158: "r",
+	0x0159: "r", 0x015A: "s", 0x015B: "s", 0x015C: "s", 0x015D: "s",
+	0x015E: "s", 0x015F: "s", 0x0160: "s", 0x0161: "s", 0x0162: "t",
+	0x0163: "t", 0x0164: "t", 0x0165: "t", 0x0168: "u", 0x0169: "u",
+	0x016A: "u", 0x016B: "u", 0x016C: "u", 0x016D: "u", 0x016E: "u",
+	0x016F: "u", 0x0170: "u", 0x0171: "u", 0x0172: "u", 0x0173: "u",
+	0x0174: "w", 0x0175: "w", 0x0176: "y", 0x0177: "y", 0x0178: "y",
+	0x0179: "z", 0x017A: "z", 0x017B: "z", 0x017C: "z", 0x017D: "z",
+	0x017E: "z", 0x017F: "s", 0x01A0: "o", 0x01A1: "o", 0x01AF: "u",
+	0x01B0: "u", 0x01C4: "dz", 0x01C5: "dz", 0x01C6: "dz", 0x01C7: "lj",
+	0x01C8: "lj", 0x01C9: "lj", 0x01CA: "nj", 0x01CB: "nj", 0x01CC: "nj",
+	0x01CD: "a", 0x01CE: "a", 0x01CF: "i", 0x01D0: "i", 0x01D1: "o",
+	0x01D2: "o", 0x01D3: "u", 0x01D4: "u", 0x01D5: "u", 0x01D6: "u",
+	0x01D7: "u", 0x01D8: "u", 0x01D9: "u", 0x01DA: "u", 0x01DB: "u",
+	0x01DC: "u", 0x01DE: "a", 0x01DF: "a", 0x01E0: "a", 0x01E1: "a",
+	0x01E2: "æ", 0x01E3: "æ", 0x01E6: "g", 0x01E7: "g", 0x01E8: "k",
+	0x01E9: "k", 0x01EA: "o", 0x01EB: "o", 0x01EC: "o", 0x01ED: "o",
+	0x01EE: "ʒ", 0x01EF: "ʒ", 0x01F0: "j", 0x01F1: "dz", 0x01F2: "dz",
+	0x01F3: "dz", 0x01F4: "g", 0x01F5: "g", 0x01F8: "n", 0x01F9: "n",
+	0x01FA: "a", 0x01FB: "a", 0x01FC: "æ", 0x01FD: "æ", 0x01FE: "ø",
+	0x01FF: "ø", 0x0200: "a", 0x0201: "a", 0x0202: "a", 0x0203: "a",
+	0x0204: "e", 0x0205: "e", 0x0206: "e", 0x0207: "e", 0x0208: "i",
+	0x0209: "i", 0x020A: "i", 0x020B: "i", 0x020C: "o", 0x020D: "o",
+	0x020E: "o", 0x020F: "o", 0x0210: "r", 0x0211: "r", 0x0212: "r",
+	0x0213: "r", 0x0214: "u", 0x0215: "u", 0x0216: "u", 0x0217: "u",
+	0x0218: "s", 0x0219: "s", 0x021A: "t", 0x021B: "t", 0x021E: "h",
+	0x021F: "h", 0x0226: "a", 0x0227: "a", 0x0228: "e", 0x0229: "e",
+	0x022A: "o", 0x022B: "o", 0x022C: "o", 0x022D: "o", 0x022E: "o",
+	0x022F: "o", 0x0230: "o", 0x0231: "o", 0x0232: "y", 0x0233: "y",
+	0x02B0: "h", 0x02B1: "ɦ", 0x02B2: "j", 0x02B3: "r", 0x02B4: "ɹ",
+	0x02B5: "ɻ", 0x02B6: "ʁ", 0x02B7: "w", 0x02B8: "y", 0x02E0: "ɣ",
+	0x02E1: "l", 0x02E2: "s", 0x02E3: "x", 0x02E4: "ʕ", 0x0374: "ʹ",
+	0x037A: " ", 0x0386: "α", 0x0388: "ε", 0x0389: "η", 0x038A: "ι",
+	0x038C: "ο", 0x038E: "υ", 0x038F: "ω", 0x0390: "ι", 0x03AA: "ι",
+	0x03AB: "υ", 0x03AC: "α", 0x03AD: "ε", 0x03AE: "η", 0x03AF: "ι",
+	0x03B0: "υ", 0x03C2: "σ", 0x03CA: "ι", 0x03CB: "υ", 0x03CC: "ο",
+	0x03CD: "υ", 0x03CE: "ω", 0x03D0: "β", 0x03D1: "θ", 0x03D2: "υ",
+	0x03D3: "υ", 0x03D4: "υ", 0x03D5: "φ", 0x03D6: "π", 0x03F0: "κ",
+	0x03F1: "ρ", 0x03F2: "σ", 0x03F5: "ε", 0x03F9: "σ", 0x0400: "е",
+	0x0401: "е", 0x0403: "г", 0x0407: "і", 0x040C: "к", 0x040D: "и",
+	0x040E: "у", 0x0419: "и", 0x0439: "и", 0x0450: "е", 0x0451: "е",
+	0x0453: "г", 0x0457: "і", 0x045C: "к", 0x045D: "и", 0x045E: "у",
+	0x0476: "ѵ", 0x0477: "ѵ", 0x04C1: "ж", 0x04C2: "ж", 0x04D0: "а",
+	0x04D1: "а", 0x04D2: "а", 0x04D3: "а", 0x04D6: "е", 0x04D7: "е",
+	0x04DA: "ә", 0x04DB: "ә", 0x04DC: "ж", 0x04DD: "ж", 0x04DE: "з",
+	0x04DF: "з", 0x04E2: "и", 0x04E3: "и", 0x04E4: "и", 0x04E5: "и",
+	0x04E6: "о", 0x04E7: "о", 0x04EA: "ө", 0x04EB: "ө", 0x04EC: "э",
+	0x04ED: "э", 0x04EE: "у", 0x04EF: "у", 0x04F0: "у", 0x04F1: "у",
+	0x04F2: "у", 0x04F3: "у", 0x04F4: "ч", 0x04F5: "ч", 0x04F8: "ы",
+	0x04F9: "ы", 0x1E00: "a", 0x1E01: "a", 0x1E02: "b", 0x1E03: "b",
+	0x1E04: "b", 0x1E05: "b", 0x1E06: "b", 0x1E07: "b", 0x1E08: "c",
+	0x1E09: "c", 0x1E0A: "d", 0x1E0B: "d", 0x1E0C: "d", 0x1E0D: "d",
+	0x1E0E: "d", 0x1E0F: "d", 0x1E10: "d", 0x1E11: "d", 0x1E12: "d",
+	0x1E13: "d", 0x1E14: "e", 0x1E15: "e", 0x1E16: "e", 0x1E17: "e",
+	0x1E18: "e", 0x1E19: "e", 0x1E1A: "e", 0x1E1B: "e", 0x1E1C: "e",
+	0x1E1D: "e", 0x1E1E: "f", 0x1E1F: "f", 0x1E20: "g", 0x1E21: "g",
+	0x1E22: "h", 0x1E23: "h", 0x1E24: "h", 0x1E25: "h", 0x1E26: "h",
+	0x1E27: "h", 0x1E28: "h", 0x1E29: "h", 0x1E2A: "h", 0x1E2B: "h",
+	0x1E2C: "i", 0x1E2D: "i", 0x1E2E: "i", 0x1E2F: "i", 0x1E30: "k",
+	0x1E31: "k", 0x1E32: "k", 0x1E33: "k", 0x1E34: "k", 0x1E35: "k",
+	0x1E36: "l", 0x1E37: "l", 0x1E38: "l", 0x1E39: "l", 0x1E3A: "l",
+	0x1E3B: "l", 0x1E3C: "l", 0x1E3D: "l", 0x1E3E: "m", 0x1E3F: "m",
+	0x1E40: "m", 0x1E41: "m", 0x1E42: "m", 0x1E43: "m", 0x1E44: "n",
+	0x1E45: "n", 0x1E46: "n", 0x1E47: "n", 0x1E48: "n", 0x1E49: "n",
+	0x1E4A: "n", 0x1E4B: "n", 0x1E4C: "o", 0x1E4D: "o", 0x1E4E: "o",
+	0x1E4F: "o", 0x1E50: "o", 0x1E51: "o", 0x1E52: "o", 0x1E53: "o",
+	0x1E54: "p", 0x1E55: "p", 0x1E56: "p", 0x1E57: "p", 0x1E58: "r",
+	0x1E59: "r", 0x1E5A: "r", 0x1E5B: "r", 0x1E5C: "r", 0x1E5D: "r",
+	0x1E5E: "r", 0x1E5F: "r", 0x1E60: "s", 0x1E61: "s", 0x1E62: "s",
+	0x1E63: "s", 0x1E64: "s", 0x1E65: "s", 0x1E66: "s", 0x1E67: "s",
+	0x1E68: "s", 0x1E69: "s", 0x1E6A: "t", 0x1E6B: "t", 0x1E6C: "t",
+	0x1E6D: "t", 0x1E6E: "t", 0x1E6F: "t", 0x1E70: "t", 0x1E71: "t",
+	0x1E72: "u", 0x1E73: "u", 0x1E74: "u", 0x1E75: "u", 0x1E76: "u",
+	0x1E77: "u", 0x1E78: "u", 0x1E79: "u", 0x1E7A: "u", 0x1E7B: "u",
+	0x1E7C: "v", 0x1E7D: "v", 0x1E7E: "v", 0x1E7F: "v", 0x1E80: "w",
+	0x1E81: "w", 0x1E82: "w", 0x1E83: "w", 0x1E84: "w", 0x1E85: "w",
+	0x1E86: "w", 0x1E87: "w", 0x1E88: "w", 0x1E89: "w", 0x1E8A: "x",
+	0x1E8B: "x", 0x1E8C: "x", 0x1E8D: "x", 0x1E8E: "y", 0x1E8F: "y",
+	0x1E90: "z", 0x1E91: "z", 0x1E92: "z", 0x1E93: "z", 0x1E94: "z",
+	0x1E95: "z", 0x1E96: "h", 0x1E97: "t", 0x1E98: "w", 0x1E99: "y",
+	0x1E9A: "aʾ", 0x1E9B: "s", 0x1E9E: "ss", 0x1EA0: "a", 0x1EA1: "a",
+	0x1EA2: "a", 0x1EA3: "a", 0x1EA4: "a", 0x1EA5: "a", 0x1EA6: "a",
+	0x1EA7: "a", 0x1EA8: "a", 0x1EA9: "a", 0x1EAA: "a", 0x1EAB: "a",
+	0x1EAC: "a", 0x1EAD: "a", 0x1EAE: "a", 0x1EAF: "a", 0x1EB0: "a",
+	0x1EB1: "a", 0x1EB2: "a", 0x1EB3: "a", 0x1EB4: "a", 0x1EB5: "a",
+	0x1EB6: "a", 0x1EB7: "a", 0x1EB8: "e", 0x1EB9: "e", 0x1EBA: "e",
+	0x1EBB: "e", 0x1EBC: "e", 0x1EBD: "e", 0x1EBE: "e", 0x1EBF: "e",
+	0x1EC0: "e", 0x1EC1: "e", 0x1EC2: "e", 0x1EC3: "e", 0x1EC4: "e",
+	0x1EC5: "e", 0x1EC6: "e", 0x1EC7: "e", 0x1EC8: "i", 0x1EC9: "i",
+	0x1ECA: "i", 0x1ECB: "i", 0x1ECC: "o", 0x1ECD: "o", 0x1ECE: "o",
+	0x1ECF: "o", 0x1ED0: "o", 0x1ED1: "o", 0x1ED2: "o", 0x1ED3: "o",
+	0x1ED4: "o", 0x1ED5: "o", 0x1ED6: "o", 0x1ED7: "o", 0x1ED8: "o",
+	0x1ED9: "o", 0x1EDA: "o", 0x1EDB: "o", 0x1EDC: "o", 0x1EDD: "o",
+	0x1EDE: "o", 0x1EDF: "o", 0x1EE0: "o", 0x1EE1: "o", 0x1EE2: "o",
+	0x1EE3: "o", 0x1EE4: "u", 0x1EE5: "u", 0x1EE6: "u", 0x1EE7: "u",
+	0x1EE8: "u", 0x1EE9: "u", 0x1EEA: "u", 0x1EEB: "u", 0x1EEC: "u",
+	0x1EED: "u", 0x1EEE: "u", 0x1EEF: "u", 0x1EF0: "u", 0x1EF1: "u",
+	0x1EF2: "y", 0x1EF3: "y", 0x1EF4: "y", 0x1EF5: "y", 0x1EF6: "y",
+	0x1EF7: "y", 0x1EF8: "y", 0x1EF9: "y", 0x1F00: "α", 0x1F01: "α",
+	0x1F02: "α", 0x1F03: "α", 0x1F04: "α", 0x1F05: "α", 0x1F06: "α",
+	0x1F07: "α", 0x1F08: "α", 0x1F09: "α", 0x1F0A: "α", 0x1F0B: "α",
+	0x1F0C: "α", 0x1F0D: "α", 0x1F0E: "α", 0x1F0F: "α", 0x1F10: "ε",
+	0x1F11: "ε", 0x1F12: "ε", 0x1F13: "ε", 0x1F14: "ε", 0x1F15: "ε",
+	0x1F18: "ε", 0x1F19: "ε", 0x1F1A: "ε", 0x1F1B: "ε", 0x1F1C: "ε",
+	0x1F1D: "ε", 0x1F20: "η", 0x1F21: "η", 0x1F22: "η", 0x1F23: "η",
+	0x1F24: "η", 0x1F25: "η", 0x1F26: "η", 0x1F27: "η", 0x1F28: "η",
+	0x1F29: "η", 0x1F2A: "η", 0x1F2B: "η", 0x1F2C: "η", 0x1F2D: "η",
+	0x1F2E: "η", 0x1F2F: "η", 0x1F30: "ι", 0x1F31: "ι", 0x1F32: "ι",
+	0x1F33: "ι", 0x1F34: "ι", 0x1F35: "ι", 0x1F36: "ι", 0x1F37: "ι",
+	0x1F38: "ι", 0x1F39: "ι", 0x1F3A: "ι", 0x1F3B: "ι", 0x1F3C: "ι",
+	0x1F3D: "ι", 0x1F3E: "ι", 0x1F3F: "ι", 0x1F40: "ο", 0x1F41: "ο",
+	0x1F42: "ο", 0x1F43: "ο", 0x1F44: "ο", 0x1F45: "ο", 0x1F48: "ο",
+	0x1F49: "ο", 0x1F4A: "ο", 0x1F4B: "ο", 0x1F4C: "ο", 0x1F4D: "ο",
+	0x1F50: "υ", 0x1F51: "υ", 0x1F52: "υ", 0x1F53: "υ", 0x1F54: "υ",
+	0x1F55: "υ", 0x1F56: "υ", 0x1F57: "υ", 0x1F59: "υ", 0x1F5B: "υ",
+	0x1F5D: "υ", 0x1F5F: "υ", 0x1F60: "ω", 0x1F61: "ω", 0x1F62: "ω",
+	0x1F63: "ω", 0x1F64: "ω", 0x1F65: "ω", 0x1F66: "ω", 0x1F67: "ω",
+	0x1F68: "ω", 0x1F69: "ω", 0x1F6A: "ω", 0x1F6B: "ω", 0x1F6C: "ω",
+	0x1F6D: "ω", 0x1F6E: "ω", 0x1F6F: "ω", 0x1F70: "α", 0x1F71: "α",
+	0x1F72: "ε", 0x1F73: "ε", 0x1F74: "η", 0x1F75: "η", 0x1F76: "ι",
+	0x1F77: "ι", 0x1F78: "ο", 0x1F79: "ο", 0x1F7A: "υ", 0x1F7B: "υ",
+	0x1F7C: "ω", 0x1F7D: "ω", 0x1F80: "α", 0x1F81: "α", 0x1F82: "α",
+	0x1F83: "α", 0x1F84: "α", 0x1F85: "α", 0x1F86: "α", 0x1F87: "α",
+	0x1F88: "α", 0x1F89: "α", 0x1F8A: "α", 0x1F8B: "α", 0x1F8C: "α",
+	0x1F8D: "α", 0x1F8E: "α", 0x1F8F: "α", 0x1F90: "η", 0x1F91: "η",
+	0x1F92: "η", 0x1F93: "η", 0x1F94: "η", 0x1F95: "η", 0x1F96: "η",
+	0x1F97: "η", 0x1F98: "η", 0x1F99: "η", 0x1F9A: "η", 0x1F9B: "η",
+	0x1F9C: "η", 0x1F9D: "η", 0x1F9E: "η", 0x1F9F: "η", 0x1FA0: "ω",
+	0x1FA1: "ω", 0x1FA2: "ω", 0x1FA3: "ω", 0x1FA4: "ω", 0x1FA5: "ω",
+	0x1FA6: "ω", 0x1FA7: "ω", 0x1FA8: "ω", 0x1FA9: "ω", 0x1FAA: "ω",
+	0x1FAB: "ω", 0x1FAC: "ω", 0x1FAD: "ω", 0x1FAE: "ω", 0x1FAF: "ω",
+	0x1FB0: "α", 0x1FB1: "α", 0x1FB2: "α", 0x1FB3: "α", 0x1FB4: "α",
+	0x1FB6: "α", 0x1FB7: "α", 0x1FB8: "α", 0x1FB9: "α", 0x1FBA: "α",
+	0x1FBB: "α", 0x1FBC: "α", 0x1FBE: "ι", 0x1FC2: "η", 0x1FC3: "η",
+	0x1FC4: "η", 0x1FC6: "η", 0x1FC7: "η", 0x1FC8: "ε", 0x1FC9: "ε",
+	0x1FCA: "η", 0x1FCB: "η", 0x1FCC: "η", 0x1FD0: "ι", 0x1FD1: "ι",
+	0x1FD2: "ι", 0x1FD3: "ι", 0x1FD6: "ι", 0x1FD7: "ι", 0x1FD8: "ι",
+	0x1FD9: "ι", 0x1FDA: "ι", 0x1FDB: "ι", 0x1FE0: "υ", 0x1FE1: "υ",
+	0x1FE2: "υ", 0x1FE3: "υ", 0x1FE4: "ρ", 0x1FE5: "ρ", 0x1FE6: "υ",
+	0x1FE7: "υ", 0x1FE8: "υ", 0x1FE9: "υ", 0x1FEA: "υ", 0x1FEB: "υ",
+	0x1FEC: "ρ", 0x1FF2: "ω", 0x1FF3: "ω", 0x1FF4: "ω", 0x1FF6: "ω",
+	0x1FF7: "ω", 0x1FF8: "ο", 0x1FF9: "ο", 0x1FFA: "ω", 0x1FFB: "ω",
+	0x1FFC: "ω", 0x2070: "0", 0x2071: "i", 0x2074: "4", 0x2075: "5",
+	0x2076: "6", 0x2077: "7", 0x2078: "8", 0x2079: "9", 0x207F: "n",
+	0x2080: "0", 0x2081: "1", 0x2082: "2", 0x2083: "3", 0x2084: "4",
+	0x2085: "5", 0x2086: "6", 0x2087: "7", 0x2088: "8", 0x2089: "9",
+	0x2090: "a", 0x2091: "e", 0x2092: "o", 0x2093: "x", 0x2094: "ə",
+	0x2095: "h", 0x2096: "k", 0x2097: "l", 0x2098: "m", 0x2099: "n",
+	0x209A: "p", 0x209B: "s", 0x209C: "t", 0x2102: "c", 0x2107: "ɛ",
+	0x210A: "g", 0x210B: "h", 0x210C: "h", 0x210D: "h", 0x210E: "h",
+	0x210F: "ħ", 0x2110: "i", 0x2111: "i", 0x2112: "l", 0x2113: "l",
+	0x2115: "n", 0x2119: "p", 0x211A: "q", 0x211B: "r", 0x211C: "r",
+	0x211D: "r", 0x2124: "z", 0x2128: "z", 0x212B: "a", 0x212C: "b",
+	0x212D: "c", 0x212F: "e", 0x2130: "e", 0x2131: "f", 0x2133: "m",
+	0x2134: "o", 0x2135: "א", 0x2136: "ב", 0x2137: "ג", 0x2138: "ד",
+	0x2139: "i", 0x213C: "π", 0x213D: "γ", 0x213E: "γ", 0x213F: "π",
+	0x2145: "d", 0x2146: "d", 0x2147: "e", 0x2148: "i", 0x2149: "j",
+	0x2150: "1⁄7", 0x2151: "1⁄9", 0x2152: "1⁄10", 0x2153: "1⁄3", 0x2154: "2⁄3",
+	0x2155: "1⁄5", 0x2156: "2⁄5", 0x2157: "3⁄5", 0x2158: "4⁄5", 0x2159: "1⁄6",
+	0x215A: "5⁄6", 0x215B: "1⁄8", 0x215C: "3⁄8", 0x215D: "5⁄8", 0x215E: "7⁄8",
+	0x215F: "1⁄", 0x2160: "i", 0x2161: "ii", 0x2162: "iii", 0x2163: "iv",
+	0x2164: "v", 0x2165: "vi", 0x2166: "vii", 0x2167: "viii", 0x2168: "ix",
+	0x2169: "x", 0x216A: "xi", 0x216B: "xii", 0x216C: "l", 0x216D: "c",
+	0x216E: "d", 0x216F: "m", 0x2170: "i", 0x2171: "ii", 0x2172: "iii",
+	0x2173: "iv", 0x2174: "v", 0x2175: "vi", 0x2176: "vii", 0x2177: "viii",
+	0x2178: "ix", 0x2179: "x", 0x217A: "xi", 0x217B: "xii", 0x217C: "l",
+	0x217D: "c", 0x217E: "d", 0x217F: "m", 0x2189: "0⁄3", 0x2460: "1",
+	0x2461: "2", 0x2462: "3", 0x2463: "4", 0x2464: "5", 0x2465: "6",
+	0x2466: "7", 0x2467: "8", 0x2468: "9", 0x2469: "10", 0x246A: "11",
+	0x246B: "12", 0x246C: "13", 0x246D: "14", 0x246E: "15", 0x246F: "16",
+	0x2470: "17", 0x2471: "18", 0x2472: "19", 0x2473: "20", 0x2474: "(1)",
+	0x2475: "(2)", 0x2476: "(3)", 0x2477: "(4)", 0x2478: "(5)", 0x2479: "(6)",
+	0x247A: "(7)", 0x247B: "(8)", 0x247C: "(9)", 0x247D: "(10)",
+	0x247E: "(11)", 0x247F: "(12)", 0x2480: "(13)", 0x2481: "(14)",
+	0x2482: "(15)", 0x2483: "(16)", 0x2484: "(17)", 0x2485: "(18)",
+	0x2486: "(19)", 0x2487: "(20)", 0x2488: "1.", 0x2489: "2.", 0x248A: "3.",
+	0x248B: "4.", 0x248C: "5.", 0x248D: "6.", 0x248E: "7.", 0x248F: "8.",
+	0x2490: "9.", 0x2491: "10.", 0x2492: "11.", 0x2493: "12.", 0x2494: "13.",
+	0x2495: "14.", 0x2496: "15.", 0x2497: "16.", 0x2498: "17.", 0x2499: "18.",
+	0x249A: "19.", 0x249B: "20.", 0x24EA: "0", 0xF900: "豈", 0xF901: "更",
+	0xF902: "車", 0xF903: "賈", 0xF904: "滑", 0xF905: "串", 0xF906: "句",
+	0xF907: "龜", 0xF908: "龜", 0xF909: "契", 0xF90A: "金", 0xF90B: "喇",
+	0xF90C: "奈", 0xF90D: "懶", 0xF90E: "癩", 0xF90F: "羅", 0xF910: "蘿",
+	0xF911: "螺", 0xF912: "裸", 0xF913: "邏", 0xF914: "樂", 0xF915: "洛",
+	0xF916: "烙", 0xF917: "珞", 0xF918: "落", 0xF919: "酪", 0xF91A: "駱",
+	0xF91B: "亂", 0xF91C: "卵", 0xF91D: "欄", 0xF91E: "爛", 0xF91F: "蘭",
+	0xF920: "鸞", 0xF921: "嵐", 0xF922: "濫", 0xF923: "藍", 0xF924: "襤",
+	0xF925: "拉", 0xF926: "臘", 0xF927: "蠟", 0xF928: "廊", 0xF929: "朗",
+	0xF92A: "浪", 0xF92B: "狼", 0xF92C: "郎", 0xF92D: "來", 0xF92E: "冷",
+	0xF92F: "勞", 0xF930: "擄", 0xF931: "櫓", 0xF932: "爐", 0xF933: "盧",
+	0xF934: "老", 0xF935: "蘆", 0xF936: "虜", 0xF937: "路", 0xF938: "露",
+	0xF939: "魯", 0xF93A: "鷺", 0xF93B: "碌", 0xF93C: "祿", 0xF93D: "綠",
+	0xF93E: "菉", 0xF93F: "錄", 0xF940: "鹿", 0xF941: "論", 0xF942: "壟",
+	0xF943: "弄", 0xF944: "籠", 0xF945: "聾", 0xF946: "牢", 0xF947: "磊",
+	0xF948: "賂", 0xF949: "雷", 0xF94A: "壘", 0xF94B: "屢", 0xF94C: "樓",
+	0xF94D: "淚", 0xF94E: "漏", 0xF94F: "累", 0xF950: "縷", 0xF951: "陋",
+	0xF952: "勒", 0xF953: "肋", 0xF954: "凜", 0xF955: "凌", 0xF956: "稜",
+	0xF957: "綾", 0xF958: "菱", 0xF959: "陵", 0xF95A: "讀", 0xF95B: "拏",
+	0xF95C: "樂", 0xF95D: "諾", 0xF95E: "丹", 0xF95F: "寧", 0xF960: "怒",
+	0xF961: "率", 0xF962: "異", 0xF963: "北", 0xF964: "磻", 0xF965: "便",
+	0xF966: "復", 0xF967: "不", 0xF968: "泌", 0xF969: "數", 0xF96A: "索",
+	0xF96B: "參", 0xF96C: "塞", 0xF96D: "省", 0xF96E: "葉", 0xF96F: "說",
+	0xF970: "殺", 0xF971: "辰", 0xF972: "沈", 0xF973: "拾", 0xF974: "若",
+	0xF975: "掠", 0xF976: "略", 0xF977: "亮", 0xF978: "兩", 0xF979: "凉",
+	0xF97A: "梁", 0xF97B: "糧", 0xF97C: "良", 0xF97D: "諒", 0xF97E: "量",
+	0xF97F: "勵", 0xF980: "呂", 0xF981: "女", 0xF982: "廬", 0xF983: "旅",
+	0xF984: "濾", 0xF985: "礪", 0xF986: "閭", 0xF987: "驪", 0xF988: "麗",
+	0xF989: "黎", 0xF98A: "力", 0xF98B: "曆", 0xF98C: "歷", 0xF98D: "轢",
+	0xF98E: "年", 0xF98F: "憐", 0xF990: "戀", 0xF991: "撚", 0xF992: "漣",
+	0xF993: "煉", 0xF994: "璉", 0xF995: "秊", 0xF996: "練", 0xF997: "聯",
+	0xF998: "輦", 0xF999: "蓮", 0xF99A: "連", 0xF99B: "鍊", 0xF99C: "列",
+	0xF99D: "劣", 0xF99E: "咽", 0xF99F: "烈", 0xF9A0: "裂", 0xF9A1: "說",
+	0xF9A2: "廉", 0xF9A3: "念", 0xF9A4: "捻", 0xF9A5: "殮", 0xF9A6: "簾",
+	0xF9A7: "獵", 0xF9A8: "令", 0xF9A9: "囹", 0xF9AA: "寧", 0xF9AB: "嶺",
+	0xF9AC: "怜", 0xF9AD: "玲", 0xF9AE: "瑩", 0xF9AF: "羚", 0xF9B0: "聆",
+	0xF9B1: "鈴", 0xF9B2: "零", 0xF9B3: "靈", 0xF9B4: "領", 0xF9B5: "例",
+	0xF9B6: "禮", 0xF9B7: "醴", 0xF9B8: "隸", 0xF9B9: "惡", 0xF9BA: "了",
+	0xF9BB: "僚", 0xF9BC: "寮", 0xF9BD: "尿", 0xF9BE: "料", 0xF9BF: "樂",
+	0xF9C0: "燎", 0xF9C1: "療", 0xF9C2: "蓼", 0xF9C3: "遼", 0xF9C4: "龍",
+	0xF9C5: "暈", 0xF9C6: "阮", 0xF9C7: "劉", 0xF9C8: "杻", 0xF9C9: "柳",
+	0xF9CA: "流", 0xF9CB: "溜", 0xF9CC: "琉", 0xF9CD: "留", 0xF9CE: "硫",
+	0xF9CF: "紐", 0xF9D0: "類", 0xF9D1: "六", 0xF9D2: "戮", 0xF9D3: "陸",
+	0xF9D4: "倫", 0xF9D5: "崙", 0xF9D6: "淪", 0xF9D7: "輪", 0xF9D8: "律",
+	0xF9D9: "慄", 0xF9DA: "栗", 0xF9DB: "率", 0xF9DC: "隆", 0xF9DD: "利",
+	0xF9DE: "吏", 0xF9DF: "履", 0xF9E0: "易", 0xF9E1: "李", 0xF9E2: "梨",
+	0xF9E3: "泥", 0xF9E4: "理", 0xF9E5: "痢", 0xF9E6: "罹", 0xF9E7: "裏",
+	0xF9E8: "裡", 0xF9E9: "里", 0xF9EA: "離", 0xF9EB: "匿", 0xF9EC: "溺",
+	0xF9ED: "吝", 0xF9EE: "燐", 0xF9EF: "璘", 0xF9F0: "藺", 0xF9F1: "隣",
+	0xF9F2: "鱗", 0xF9F3: "麟", 0xF9F4: "林", 0xF9F5: "淋", 0xF9F6: "臨",
+	0xF9F7: "立", 0xF9F8: "笠", 0xF9F9: "粒", 0xF9FA: "狀", 0xF9FB: "炙",
+	0xF9FC: "識", 0xF9FD: "什", 0xF9FE: "茶", 0xF9FF: "刺", 0xFA00: "切",
+	0xFA01: "度", 0xFA02: "拓", 0xFA03: "糖", 0xFA04: "宅", 0xFA05: "洞",
+	0xFA06: "暴", 0xFA07: "輻", 0xFA08: "行", 0xFA09: "降", 0xFA0A: "見",
+	0xFA0B: "廓", 0xFA0C: "兀", 0xFA0D: "嗀", 0xFA10: "塚", 0xFA12: "晴",
+	0xFA15: "凞", 0xFA16: "猪", 0xFA17: "益", 0xFA18: "礼", 0xFA19: "神",
+	0xFA1A: "祥", 0xFA1B: "福", 0xFA1C: "靖", 0xFA1D: "精", 0xFA1E: "羽",
+	0xFA20: "蘒", 0xFA22: "諸", 0xFA25: "逸", 0xFA26: "都", 0xFA2A: "飯",
+	0xFA2B: "飼", 0xFA2C: "館", 0xFA2D: "鶴", 0xFA2E: "郞", 0xFA2F: "隷",
+	0xFA30: "侮", 0xFA31: "僧", 0xFA32: "免", 0xFA33: "勉", 0xFA34: "勤",
+	0xFA35: "卑", 0xFA36: "喝", 0xFA37: "嘆", 0xFA38: "器", 0xFA39: "塀",
+	0xFA3A: "墨", 0xFA3B: "層", 0xFA3C: "屮", 0xFA3D: "悔", 0xFA3E: "慨",
+	0xFA3F: "憎", 0xFA40: "懲", 0xFA41: "敏", 0xFA42: "既", 0xFA43: "暑",
+	0xFA44: "梅", 0xFA45: "海", 0xFA46: "渚", 0xFA47: "漢", 0xFA48: "煮",
+	0xFA49: "爫", 0xFA4A: "琢", 0xFA4B: "碑", 0xFA4C: "社", 0xFA4D: "祉",
+	0xFA4E: "祈", 0xFA4F: "祐", 0xFA50: "祖", 0xFA51: "祝", 0xFA52: "禍",
+	0xFA53: "禎", 0xFA54: "穀", 0xFA55: "突", 0xFA56: "節", 0xFA57: "練",
+	0xFA58: "縉", 0xFA59: "繁", 0xFA5A: "署", 0xFA5B: "者", 0xFA5C: "臭",
+	0xFA5D: "艹", 0xFA5E: "艹", 0xFA5F: "著", 0xFA60: "褐", 0xFA61: "視",
+	0xFA62: "謁", 0xFA63: "謹", 0xFA64: "賓", 0xFA65: "贈", 0xFA66: "辶",
+	0xFA67: "逸", 0xFA68: "難", 0xFA69: "響", 0xFA6A: "頻", 0xFA6B: "恵",
+	0xFA6C: "𤋮", 0xFA6D: "舘", 0xFA70: "並", 0xFA71: "况", 0xFA72: "全",
+	0xFA73: "侀", 0xFA74: "充", 0xFA75: "冀", 0xFA76: "勇", 0xFA77: "勺",
+	0xFA78: "喝", 0xFA79: "啕", 0xFA7A: "喙", 0xFA7B: "嗢", 0xFA7C: "塚",
+	0xFA7D: "墳", 0xFA7E: "奄", 0xFA7F: "奔", 0xFA80: "婢", 0xFA81: "嬨",
+	0xFA82: "廒", 0xFA83: "廙", 0xFA84: "彩", 0xFA85: "徭", 0xFA86: "惘",
+	0xFA87: "慎", 0xFA88: "愈", 0xFA89: "憎", 0xFA8A: "慠", 0xFA8B: "懲",
+	0xFA8C: "戴", 0xFA8D: "揄", 0xFA8E: "搜", 0xFA8F: "摒", 0xFA90: "敖",
+	0xFA91: "晴", 0xFA92: "朗", 0xFA93: "望", 0xFA94: "杖", 0xFA95: "歹",
+	0xFA96: "殺", 0xFA97: "流", 0xFA98: "滛", 0xFA99: "滋", 0xFA9A: "漢",
+	0xFA9B: "瀞", 0xFA9C: "煮", 0xFA9D: "瞧", 0xFA9E: "爵", 0xFA9F: "犯",
+	0xFAA0: "猪", 0xFAA1: "瑱", 0xFAA2: "甆", 0xFAA3: "画", 0xFAA4: "瘝",
+	0xFAA5: "瘟", 0xFAA6: "益", 0xFAA7: "盛", 0xFAA8: "直", 0xFAA9: "睊",
+	0xFAAA: "着", 0xFAAB: "磌", 0xFAAC: "窱", 0xFAAD: "節", 0xFAAE: "类",
+	0xFAAF: "絛", 0xFAB0: "練", 0xFAB1: "缾", 0xFAB2: "者", 0xFAB3: "荒",
+	0xFAB4: "華", 0xFAB5: "蝹", 0xFAB6: "襁", 0xFAB7: "覆", 0xFAB8: "視",
+	0xFAB9: "調", 0xFABA: "諸", 0xFABB: "請", 0xFABC: "謁", 0xFABD: "諾",
+	0xFABE: "諭", 0xFABF: "謹", 0xFAC0: "變", 0xFAC1: "贈", 0xFAC2: "輸",
+	0xFAC3: "遲", 0xFAC4: "醙", 0xFAC5: "鉶", 0xFAC6: "陼", 0xFAC7: "難",
+	0xFAC8: "靖", 0xFAC9: "韛", 0xFACA: "響", 0xFACB: "頋", 0xFACC: "頻",
+	0xFACD: "鬒", 0xFACE: "龜", 0xFACF: "𢡊", 0xFAD0: "𢡄", 0xFAD1: "𣏕",
+	0xFAD2: "㮝", 0xFAD3: "䀘", 0xFAD4: "䀹", 0xFAD5: "𥉉", 0xFAD6: "𥳐",
+	0xFAD7: "𧻓", 0xFAD8: "齃", 0xFAD9: "龎", 0xFB00: "ff", 0xFB01: "fi",
+	0xFB02: "fl", 0xFB03: "ffi", 0xFB04: "ffl", 0xFB05: "st", 0xFB06: "st",
+	0xFB13: "մն", 0xFB14: "մե", 0xFB15: "մի", 0xFB16: "վն", 0xFB17: "մխ",
+	0xFB1D: "י", 0xFB1F: "ײ", 0xFB20: "ע", 0xFB21: "א", 0xFB22: "ד",
+	0xFB23: "ה", 0xFB24: "כ", 0xFB25: "ל", 0xFB26: "ם", 0xFB27: "ר",
+	0xFB28: "ת", 0xFB2A: "ש", 0xFB2B: "ש", 0xFB2C: "ש", 0xFB2D: "ש",
+	0xFB2E: "א", 0xFB2F: "א", 0xFB30: "א", 0xFB31: "ב", 0xFB32: "ג",
+	0xFB33: "ד", 0xFB34: "ה", 0xFB35: "ו", 0xFB36: "ז", 0xFB38: "ט",
+	0xFB39: "י", 0xFB3A: "ך", 0xFB3B: "כ", 0xFB3C: "ל", 0xFB3E: "מ",
+	0xFB40: "נ", 0xFB41: "ס", 0xFB43: "ף", 0xFB44: "פ", 0xFB46: "צ",
+	0xFB47: "ק", 0xFB48: "ר", 0xFB49: "ש", 0xFB4A: "ת", 0xFB4B: "ו",
+	0xFB4C: "ב", 0xFB4D: "כ", 0xFB4E: "פ", 0xFB4F: "אל", 0xFF10: "0",
+	0xFF11: "1", 0xFF12: "2", 0xFF13: "3", 0xFF14: "4", 0xFF15: "5",
+	0xFF16: "6", 0xFF17: "7", 0xFF18: "8", 0xFF19: "9", 0xFF21: "a",
+	0xFF22: "b", 0xFF23: "c", 0xFF24: "d", 0xFF25: "e", 0xFF26: "f",
+	0xFF27: "g", 0xFF28: "h", 0xFF29: "i", 0xFF2A: "j", 0xFF2B: "k",
+	0xFF2C: "l", 0xFF2D: "m", 0xFF2E: "n", 0xFF2F: "o", 0xFF30: "p",
+	0xFF31: "q", 0xFF32: "r", 0xFF33: "s", 0xFF34: "t", 0xFF35: "u",
+	0xFF36: "v", 0xFF37: "w", 0xFF38: "x", 0xFF39: "y", 0xFF3A: "z",
+	0xFF41: "a", 0xFF42: "b", 0xFF43: "c", 0xFF44: "d", 0xFF45: "e",
+	0xFF46: "f", 0xFF47: "g", 0xFF48: "h", 0xFF49: "i", 0xFF4A: "j",
+	0xFF4B: "k", 0xFF4C: "l", 0xFF4D: "m", 0xFF4E: "n", 0xFF4F: "o",
+	0xFF50: "p", 0xFF51: "q", 0xFF52: "r", 0xFF53: "s", 0xFF54: "t",
+	0xFF55: "u", 0xFF56: "v", 0xFF57: "w", 0xFF58: "x", 0xFF59: "y",
+	0xFF5A: "z", 0xFF66: "ヲ", 0xFF67: "ァ", 0xFF68: "ィ", 0xFF69: "ゥ",
+	0xFF6A: "ェ", 0xFF6B: "ォ", 0xFF6C: "ャ", 0xFF6D: "ュ", 0xFF6E: "ョ",
+	0xFF6F: "ッ", 0xFF70: "ー", 0xFF71: "ア", 0xFF72: "イ", 0xFF73: "ウ",
+	0xFF74: "エ", 0xFF75: "オ", 0xFF76: "カ", 0xFF77: "キ", 0xFF78: "ク",
+	0xFF79: "ケ", 0xFF7A: "コ", 0xFF7B: "サ", 0xFF7C: "シ", 0xFF7D: "ス",
+	0xFF7E: "セ", 0xFF7F: "ソ", 0xFF80: "タ", 0xFF81: "チ", 0xFF82: "ツ",
+	0xFF83: "テ", 0xFF84: "ト", 0xFF85: "ナ", 0xFF86: "ニ", 0xFF87: "ヌ",
+	0xFF88: "ネ", 0xFF89: "ノ", 0xFF8A: "ハ", 0xFF8B: "ヒ", 0xFF8C: "フ",
+	0xFF8D: "ヘ", 0xFF8E: "ホ", 0xFF8F: "マ", 0xFF90: "ミ", 0xFF91: "ム",
+	0xFF92: "メ", 0xFF93: "モ", 0xFF94: "ヤ", 0xFF95: "ユ", 0xFF96: "ヨ",
+	0xFF97: "ラ", 0xFF98: "リ", 0xFF99: "ル", 0xFF9A: "レ", 0xFF9B: "ロ",
+	0xFF9C: "ワ", 0xFF9D: "ン", 0xFF9E: "", 0xFF9F: "", 0xFFA0: "ᅠ", 0xFFA1: "ᄀ",
+	0xFFA2: "ᄁ", 0xFFA3: "ᆪ", 0xFFA4: "ᄂ", 0xFFA5: "ᆬ", 0xFFA6: "ᆭ",
+	0xFFA7: "ᄃ", 0xFFA8: "ᄄ", 0xFFA9: "ᄅ", 0xFFAA: "ᆰ", 0xFFAB: "ᆱ",
+	0xFFAC: "ᆲ", 0xFFAD: "ᆳ", 0xFFAE: "ᆴ", 0xFFAF: "ᆵ", 0xFFB0: "ᄚ",
+	0xFFB1: "ᄆ", 0xFFB2: "ᄇ", 0xFFB3: "ᄈ", 0xFFB4: "ᄡ", 0xFFB5: "ᄉ",
+	0xFFB6: "ᄊ", 0xFFB7: "ᄋ", 0xFFB8: "ᄌ", 0xFFB9: "ᄍ", 0xFFBA: "ᄎ",
+	0xFFBB: "ᄏ", 0xFFBC: "ᄐ", 0xFFBD: "ᄑ", 0xFFBE: "ᄒ", 0xFFC2: "ᅡ",
+	0xFFC3: "ᅢ", 0xFFC4: "ᅣ", 0xFFC5: "ᅤ", 0xFFC6: "ᅥ", 0xFFC7: "ᅦ",
+	0xFFCA: "ᅧ", 0xFFCB: "ᅨ", 0xFFCC: "ᅩ", 0xFFCD: "ᅪ", 0xFFCE: "ᅫ",
+	0xFFCF: "ᅬ", 0xFFD2: "ᅭ", 0xFFD3: "ᅮ", 0xFFD4: "ᅯ", 0xFFD5: "ᅰ",
+	0xFFD6: "ᅱ", 0xFFD7: "ᅲ", 0xFFDA: "ᅳ", 0xFFDB: "ᅴ", 0xFFDC: "ᅵ",
+}
@@ -0,0 +1,180 @@
+package resources
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+var (
+	historyCollectionName = []byte("History")
+
+	// revision field names, besides article field names
+	fSavedAt = []byte("SavedAt")
+)
+
+// ErrRevisionNotFound returned when a revision does not exist
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Revision a saved version of an article
+type Revision struct {
+	Number  uint64
+	SavedAt time.Time
+	Article
+}
+
+// RevisionInfo brief of a revision
+type RevisionInfo struct {
+	Number  uint64    `json:"revision"`
+	SavedAt time.Time `json:"savedAt"`
+	Title   string    `json:"title"`
+}
+
+// Revisions list revisions of article, the newest first
+func (a *Article) Revisions() (revs []*RevisionInfo, err error) {
+	err = db.View(func(tx Tx) error {
+		h := articleHistory(tx, a.ID)
+		if h == nil {
+			return nil
+		}
+
+		cursor := h.Cursor()
+		for k, _ := cursor.Last(); k != nil; k, _ = cursor.Prev() {
+			b := h.Bucket(k)
+			revs = append(revs, &RevisionInfo{
+				Number:  binary.BigEndian.Uint64(k),
+				SavedAt: parseTime(b.Get(fSavedAt)),
+				Title:   string(b.Get(fTitle)),
+			})
+		}
+		return nil
+	})
+	return
+}
+
+// GetRevision get a revision of article by its number
+func (a *Article) GetRevision(number uint64) (*Revision, error) {
+	r := &Revision{Number: number, Article: Article{ID: a.ID}}
+	err := db.View(func(tx Tx) error {
+		h := articleHistory(tx, a.ID)
+		if h == nil {
+			return ErrRevisionNotFound
+		}
+		b := h.Bucket(revisionKey(number))
+		if b == nil {
+			return ErrRevisionNotFound
+		}
+
+		r.SavedAt = parseTime(b.Get(fSavedAt))
+		r.Parent = string(b.Get(fParent))
+		r.Title = string(b.Get(fTitle))
+		r.Content = string(b.Get(fContent))
+		r.Diagram = string(b.Get(fDiagram))
+		r.Tags = decodeTags(b.Get(fTags))
+		r.Version = decodeVersion(b.Get(fVersion))
+		return nil
+	})
+	return r, err
+}
+
+// AtVersion get article a as it was at version, from its history. Only
+// updates keep revisions, a revision of version n also holds what article
+// was at versions since the previous revision. a itself is returned if it
+// has not been updated since version.
+func (a *Article) AtVersion(version uint64) (*Article, error) {
+	if version == 0 || version > a.Version {
+		return nil, ErrRevisionNotFound
+	}
+	number := uint64(0)
+	err := db.View(func(tx Tx) error {
+		h := articleHistory(tx, a.ID)
+		if h == nil {
+			return nil
+		}
+		cursor := h.Cursor()
+		for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+			if decodeVersion(h.Bucket(k).Get(fVersion)) >= version {
+				number = binary.BigEndian.Uint64(k)
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil || number == 0 {
+		return a, err
+	}
+	r, err := a.GetRevision(number)
+	if err != nil {
+		return nil, err
+	}
+	return &r.Article, nil
+}
+
+// Rollback restore title, content and diagram of article from a revision,
+// the version being replaced is kept as a new revision.
+// Parent is not rolled back, since tree may have been changed.
+func (a *Article) Rollback(number uint64) error {
+	r, err := a.GetRevision(number)
+	if err != nil {
+		return err
+	}
+
+	a.Title = r.Title
+	a.Content = r.Content
+	a.Diagram = r.Diagram
+	return a.Update(false, true, true, true, false)
+}
+
+// saveRevision append current version of article (in b) to its history
+func saveRevision(tx Tx, id string, b Bucket) error {
+	c, err := tx.CreateBucketIfNotExists(historyCollectionName)
+	if err != nil {
+		return err
+	}
+	h, err := c.CreateBucketIfNotExists([]byte(id))
+	if err != nil {
+		return err
+	}
+	seq, err := h.NextSequence()
+	if err != nil {
+		return err
+	}
+	r, err := h.CreateBucket(revisionKey(seq))
+	if err != nil {
+		return err
+	}
+
+	if err = r.Put(fSavedAt, formatTime(time.Now())); err != nil {
+		return err
+	}
+	for _, f := range [][]byte{fParent, fTitle, fContent, fDiagram, fTags,
+		fVersion} {
+		if err = r.Put(f, b.Get(f)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteHistory remove all revisions of article
+func deleteHistory(tx Tx, id string) error {
+	c := tx.Bucket(historyCollectionName)
+	if c == nil || c.Bucket([]byte(id)) == nil {
+		return nil
+	}
+	return c.DeleteBucket([]byte(id))
+}
+
+func articleHistory(tx Tx, id string) Bucket {
+	c := tx.Bucket(historyCollectionName)
+	if c == nil {
+		return nil
+	}
+	return c.Bucket([]byte(id))
+}
+
+func revisionKey(number uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, number)
+	return k
+}
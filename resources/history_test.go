@@ -0,0 +1,70 @@
+package resources
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRevisions(t *testing.T) {
+	openTestDatabase(t)
+	a := createArticle(t, RootArticleID, "a")
+	if a.Version != 1 {
+		t.Errorf("version of a new article = %d, want 1", a.Version)
+	}
+
+	a.Title, a.Content, a.Tags = "a2", "changed", []string{"x"}
+	if err := a.Update(false, true, true, false, true); err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetArticle(a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "a2" || got.Content != "changed" || got.Version != 2 ||
+		!reflect.DeepEqual(got.Tags, []string{"x"}) {
+		t.Errorf("updated article = %+v", got)
+	}
+
+	revs, err := a.Revisions()
+	if err != nil || len(revs) != 1 {
+		t.Fatalf("Revisions = %v, %v, want one", revs, err)
+	}
+	old, err := a.AtVersion(1)
+	if err != nil || old.Title != "a" || old.Content != "a content" {
+		t.Errorf("AtVersion(1) = %+v, %v", old, err)
+	}
+}
+
+func TestRevisionsOfEveryVersion(t *testing.T) {
+	openTestDatabase(t)
+	a := createArticle(t, RootArticleID, "a")
+	steps := []func() error{
+		func() error { return a.SetPrivate("password") },
+		func() error { return a.ClearPrivate() },
+		func() error { return a.SetTemplate(true) },
+		func() error { return a.MoveToTrash() },
+		func() error { return RestoreFromTrash(a.ID, RootArticleID) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+	got, err := GetArticle(a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revs, err := got.Revisions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every version but the current one is kept
+	if uint64(len(revs)) != got.Version-1 {
+		t.Errorf("%d revisions of version %d", len(revs), got.Version)
+	}
+	for v := uint64(1); v < got.Version; v++ {
+		if _, err := got.AtVersion(v); err != nil {
+			t.Errorf("AtVersion(%d): %v", v, err)
+		}
+	}
+}
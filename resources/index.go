@@ -0,0 +1,74 @@
+package resources
+
+// secondaryIndexes names of secondary indexes
+var secondaryIndexes = [][]byte{
+	tagsCollectionName,
+	titlesCollectionName,
+	linksCollectionName,
+	searchIndexCollectionName,
+	searchDocsCollectionName,
+	searchStatsCollectionName,
+}
+
+// indexArticle add article stored in b to secondary indexes
+func indexArticle(tx Tx, id []byte, b Bucket) error {
+	if err := indexTags(tx, id, decodeTags(b.Get(fTags))); err != nil {
+		return err
+	}
+	if err := indexText(tx, id, b); err != nil {
+		return err
+	}
+	return indexTitleAndLinks(tx, id, b)
+}
+
+// unindexArticle remove article stored in b from secondary indexes
+func unindexArticle(tx Tx, id []byte, b Bucket) error {
+	if err := unindexTags(tx, id, decodeTags(b.Get(fTags))); err != nil {
+		return err
+	}
+	if err := unindexText(tx, id, b); err != nil {
+		return err
+	}
+	return unindexTitleAndLinks(tx, id, b)
+}
+
+// removeFromIndex remove id from entry key of index name,
+// the entry is dropped when it becomes empty
+func removeFromIndex(tx Tx, name []byte, key string, id []byte) error {
+	x := tx.Bucket(name)
+	if x == nil {
+		return nil
+	}
+	e := x.Bucket([]byte(key))
+	if e == nil {
+		return nil
+	}
+	if err := e.Delete(id); err != nil {
+		return err
+	}
+	if k, _ := e.Cursor().First(); k == nil {
+		return x.DeleteBucket([]byte(key))
+	}
+	return nil
+}
+
+// rebuildIndexes drop secondary indexes and build them from all articles
+func rebuildIndexes(tx Tx) error {
+	for _, name := range secondaryIndexes {
+		if tx.Bucket(name) != nil {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.CreateBucket(name); err != nil {
+			return err
+		}
+	}
+	c, err := articleCollection(tx)
+	if err != nil {
+		return err
+	}
+	return c.ForEach(func(k, _ []byte) error {
+		return indexArticle(tx, k, c.Bucket(k))
+	})
+}
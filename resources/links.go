@@ -0,0 +1,259 @@
+package resources
+
+import (
+	"html"
+	"strings"
+)
+
+// link related index names
+var (
+	// from normalized title to ids of articles
+	titlesCollectionName = []byte("Titles")
+	// from link target, which is an article id or a normalized title,
+	// to ids of articles linking to it
+	linksCollectionName = []byte("Links")
+)
+
+// wikiLink a [[target]] or [[target|label]] in content
+type wikiLink struct {
+	start, end    int
+	target, label string
+}
+
+// Backlinks list articles which link to article, except those hidden by
+// locked private articles
+func (a *Article) Backlinks(session string) (articles []*ArticleTitle, err error) {
+	err = db.View(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		l := tx.Bucket(linksCollectionName)
+		if l == nil {
+			return nil
+		}
+
+		seen := make(map[string]bool)
+		pv := newPrivacy(c, session)
+		for _, target := range []string{a.ID, normalizeTitle(a.Title)} {
+			x := l.Bucket([]byte(target))
+			if x == nil {
+				continue
+			}
+			x.ForEach(func(k, _ []byte) error {
+				if b := c.Bucket(k); b != nil && !seen[string(k)] &&
+					!pv.hidden(string(k)) {
+					seen[string(k)] = true
+					articles = append(articles, articleTitle(k, b))
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return
+}
+
+// renderWikiLinks replace wiki links in content with markdown links to
+// articles, links to missing articles, or to articles hidden from session,
+// are marked as broken
+func renderWikiLinks(session, content string) string {
+	links := findWikiLinks(content)
+	if len(links) == 0 {
+		return content
+	}
+
+	ids := make([]string, len(links))
+	db.View(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		pv := newPrivacy(c, session)
+		for i, l := range links {
+			ids[i] = resolveWikiLink(tx, pv, l.target)
+		}
+		return nil
+	})
+
+	var out strings.Builder
+	last := 0
+	for i, l := range links {
+		out.WriteString(content[last:l.start])
+		if ids[i] == "" {
+			out.WriteString(`<span class="broken-link" title="article not found">`)
+			out.WriteString(html.EscapeString(l.label))
+			out.WriteString(`</span>`)
+		} else {
+			out.WriteString("[")
+			out.WriteString(markdownEscaper.Replace(l.label))
+			out.WriteString("](/assets/#")
+			out.WriteString(ids[i])
+			out.WriteString(")")
+		}
+		last = l.end
+	}
+	out.WriteString(content[last:])
+	return out.String()
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, `[`, `\[`, `]`, `\]`, `*`, `\*`, `_`, `\_`, "`", "\\`", `<`, `&lt;`)
+
+// resolveWikiLink return id of the article target refers to, by id or by
+// title, empty if there is no such article which pv does not hide
+func resolveWikiLink(tx Tx, pv *privacy, target string) string {
+	if pv.c.Bucket([]byte(target)) != nil {
+		if pv.hidden(target) {
+			return ""
+		}
+		return target
+	}
+
+	t := tx.Bucket(titlesCollectionName)
+	if t == nil {
+		return ""
+	}
+	x := t.Bucket([]byte(normalizeTitle(target)))
+	if x == nil {
+		return ""
+	}
+	var id string
+	x.ForEach(func(k, _ []byte) error {
+		if id == "" && !pv.hidden(string(k)) {
+			id = string(k)
+		}
+		return nil
+	})
+	return id
+}
+
+// findWikiLinks find wiki links in markdown source, code is skipped
+func findWikiLinks(src string) (links []wikiLink) {
+	fenced := false
+	offset := 0
+	for _, line := range strings.SplitAfter(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+		} else if !fenced && !strings.HasPrefix(line, "    ") &&
+			!strings.HasPrefix(line, "\t") {
+			links = append(links, findWikiLinksInLine(line, offset)...)
+		}
+		offset += len(line)
+	}
+	return
+}
+
+func findWikiLinksInLine(line string, offset int) (links []wikiLink) {
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '`':
+			// skip code span
+			n := 1
+			for i+n < len(line) && line[i+n] == '`' {
+				n++
+			}
+			end := strings.Index(line[i+n:], strings.Repeat("`", n))
+			if end == -1 {
+				return
+			}
+			i += n + end + n - 1
+		case strings.HasPrefix(line[i:], "[["):
+			end := strings.Index(line[i+2:], "]]")
+			if end == -1 {
+				return
+			}
+			inner := line[i+2 : i+2+end]
+			if strings.Contains(inner, "[[") {
+				continue
+			}
+			target, label := inner, inner
+			if p := strings.Index(inner, "|"); p != -1 {
+				target, label = inner[:p], inner[p+1:]
+			}
+			target, label = strings.TrimSpace(target), strings.TrimSpace(label)
+			if target == "" {
+				continue
+			}
+			if label == "" {
+				label = target
+			}
+			links = append(links, wikiLink{
+				start:  offset + i,
+				end:    offset + i + 2 + end + 2,
+				target: target,
+				label:  label,
+			})
+			i += 2 + end + 1
+		}
+	}
+	return
+}
+
+// linkTargets return distinct link targets in content, as they are indexed
+func linkTargets(content []byte) []string {
+	var targets []string
+	seen := make(map[string]bool)
+	for _, l := range findWikiLinks(string(content)) {
+		t := normalizeTitle(l.target)
+		if !seen[t] {
+			seen[t] = true
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+func indexTitleAndLinks(tx Tx, id []byte, b Bucket) error {
+	t, err := tx.CreateBucketIfNotExists(titlesCollectionName)
+	if err != nil {
+		return err
+	}
+	x, err := t.CreateBucketIfNotExists([]byte(normalizeTitle(string(b.Get(fTitle)))))
+	if err != nil {
+		return err
+	}
+	if err = x.Put(id, []byte{}); err != nil {
+		return err
+	}
+
+	l, err := tx.CreateBucketIfNotExists(linksCollectionName)
+	if err != nil {
+		return err
+	}
+	for _, target := range linkTargets(b.Get(fContent)) {
+		x, err := l.CreateBucketIfNotExists([]byte(target))
+		if err != nil {
+			return err
+		}
+		if err = x.Put(id, []byte{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unindexTitleAndLinks(tx Tx, id []byte, b Bucket) error {
+	err := removeFromIndex(tx, titlesCollectionName,
+		normalizeTitle(string(b.Get(fTitle))), id)
+	if err != nil {
+		return err
+	}
+	for _, target := range linkTargets(b.Get(fContent)) {
+		if err := removeFromIndex(tx, linksCollectionName, target, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeTitle normalize title or link target for matching
+func normalizeTitle(title string) string {
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		// bolt does not accept empty key
+		return " "
+	}
+	return strings.ToLower(title)
+}
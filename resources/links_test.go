@@ -0,0 +1,117 @@
+package resources
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindWikiLinks(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"[[a]] and [[ b | label ]]", []string{"a|a", "b|label"}},
+		{"[[]] [[|x]] [[a", []string{}},
+		{"`[[code]]` ``x`[[code]]`` [[a]]", []string{"a|a"}},
+		{"```\n[[fenced]]\n```\n    [[indented]]\n[[a]]", []string{"a|a"}},
+		{"[[a [[b]]", []string{"b|b"}},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, l := range findWikiLinks(tt.src) {
+			got = append(got, l.target+"|"+l.label)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findWikiLinks(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+// backlinkTitles titles of articles linking to a, sorted
+func backlinkTitles(t *testing.T, session string, a *Article) []string {
+	articles, err := a.Backlinks(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := []string{}
+	for _, b := range articles {
+		titles = append(titles, b.Title)
+	}
+	sort.Strings(titles)
+	return titles
+}
+
+func TestWikiLinks(t *testing.T) {
+	openTestDatabase(t)
+	pie := createArticle(t, RootArticleID, "Apple Pie")
+	for title, content := range map[string]string{
+		"by title": "[[apple  PIE]]",
+		"by id":    "[[" + pie.ID + "|the pie]]",
+		"in code":  "`[[Apple Pie]]`",
+	} {
+		a := createArticle(t, RootArticleID, title)
+		a.Content = content
+		if err := a.Update(false, false, true, false, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := backlinkTitles(t, "", pie), []string{"by id", "by title"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("backlinks = %v, want %v", got, want)
+	}
+
+	got := renderWikiLinks("", "[[apple pie]], [[Missing|a_b]]")
+	want := "[apple pie](/assets/#" + pie.ID + "), " +
+		`<span class="broken-link" title="article not found">a_b</span>`
+	if got != want {
+		t.Errorf("rendered = %q, want %q", got, want)
+	}
+
+	// renaming changes which links resolve
+	pie.Title = "Cherry Pie"
+	if err := pie.Update(false, true, false, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := backlinkTitles(t, "", pie), []string{"by id"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("backlinks after renaming = %v, want %v", got, want)
+	}
+	if got := renderWikiLinks("", "[[Apple Pie]]"); !strings.Contains(got, "broken-link") {
+		t.Errorf("link to the old title = %q", got)
+	}
+}
+
+func TestWikiLinksOfPrivateArticles(t *testing.T) {
+	openTestDatabase(t)
+	pie := createArticle(t, RootArticleID, "Apple Pie")
+	private := createArticle(t, RootArticleID, "private")
+	secret := createArticle(t, private.ID, "Secret Recipe")
+	secret.Content = "[[Apple Pie]]"
+	if err := secret.Update(false, false, true, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := private.SetPrivate("password"); err != nil {
+		t.Fatal(err)
+	}
+
+	// neither links to nor links from hidden articles are shown
+	if got := backlinkTitles(t, "s1", pie); len(got) != 0 {
+		t.Errorf("backlinks of a locked session = %v", got)
+	}
+	for _, target := range []string{"secret recipe", secret.ID} {
+		if got := renderWikiLinks("s1", "[["+target+"]]"); !strings.Contains(got, "broken-link") {
+			t.Errorf("link to %s of a locked session = %q", target, got)
+		}
+	}
+
+	if err := UnlockPrivate("s1", private.ID, "password", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := backlinkTitles(t, "s1", pie), []string{"Secret Recipe"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("backlinks of an unlocked session = %v, want %v", got, want)
+	}
+	if got := renderWikiLinks("s1", "[[Secret Recipe]]"); !strings.Contains(got, secret.ID) {
+		t.Errorf("link of an unlocked session = %q", got)
+	}
+}
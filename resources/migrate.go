@@ -0,0 +1,128 @@
+package resources
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// metaCollectionName names the collection of database metadata
+var metaCollectionName = []byte("Meta")
+
+// meta field names
+var fSchemaVersion = []byte("SchemaVersion")
+
+// migration upgrade database layout to version
+type migration struct {
+	version     int
+	description string
+	up          func(tx Tx) error
+}
+
+// migrations in order of version. Databases written before versions were
+// recorded have version 0, so every migration must cope with layouts of
+// any earlier version. Append a migration whenever layout or encoding of
+// stored data changes, never change released ones.
+var migrations = []migration{
+	{1, "create article collection and root article", createRootArticle},
+	{2, "build children index", func(tx Tx) error {
+		if tx.Bucket(childrenCollectionName) != nil {
+			return nil
+		}
+		return buildChildrenIndex(tx, nil)
+	}},
+	{3, "build tags, titles, links and search indexes", rebuildIndexes},
+	{4, "keep search statistics", keepSearchStats},
+}
+
+// SchemaVersionError returned when a database was written by a newer
+// version of this program
+type SchemaVersionError struct {
+	Version, Supported int
+}
+
+func (e *SchemaVersionError) Error() string {
+	return fmt.Sprintf("database schema version %d is newer than supported "+
+		"version %d, please upgrade this program", e.Version, e.Supported)
+}
+
+// MigrateDatabase upgrade black to the latest schema version
+func MigrateDatabase(black Storage) error {
+	return black.Update(migrate)
+}
+
+// migrate run migrations newer than schema version of database
+func migrate(tx Tx) error {
+	version, err := schemaVersion(tx)
+	if err != nil {
+		return err
+	}
+
+	meta, err := tx.CreateBucketIfNotExists(metaCollectionName)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if err := m.up(tx); err != nil {
+			return fmt.Errorf("migrating to schema version %d (%s): %v",
+				m.version, m.description, err)
+		}
+		err := meta.Put(fSchemaVersion, []byte(strconv.Itoa(m.version)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schemaVersion return schema version recorded in database, 0 if none.
+// An error is returned if it is newer than the latest migration.
+func schemaVersion(tx Tx) (int, error) {
+	meta := tx.Bucket(metaCollectionName)
+	if meta == nil || meta.Get(fSchemaVersion) == nil {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(string(meta.Get(fSchemaVersion)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q",
+			meta.Get(fSchemaVersion))
+	}
+	if latest := migrations[len(migrations)-1].version; v > latest {
+		return 0, &SchemaVersionError{Version: v, Supported: latest}
+	}
+	return v, nil
+}
+
+// createRootArticle create article collection and root article if they
+// do not exist
+func createRootArticle(tx Tx) error {
+	c, err := tx.CreateBucketIfNotExists(articleCollectionName)
+	if err != nil {
+		return err
+	}
+	if c.Bucket([]byte(RootArticleID)) != nil {
+		return nil
+	}
+
+	b, err := c.CreateBucket([]byte(RootArticleID))
+	if err != nil {
+		return err
+	}
+	if err = b.Put(fTitle, []byte("First Article")); err != nil {
+		return err
+	}
+	if err = b.Put(fContent, []byte("You can edit this article.")); err != nil {
+		return err
+	}
+	now := formatTime(time.Now())
+	if err = b.Put(fCreatedAt, now); err != nil {
+		return err
+	}
+	if err = b.Put(fUpdatedAt, now); err != nil {
+		return err
+	}
+	return b.Put(fVersion, encodeVersion(1))
+}
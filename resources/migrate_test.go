@@ -0,0 +1,122 @@
+package resources
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// downgrade turn the database in use back to the layout of schema
+// version, dropping collections added since
+func downgrade(t *testing.T, version int) {
+	var added [][]byte
+	if version < 2 {
+		added = append(added, childrenCollectionName)
+	}
+	if version < 3 {
+		added = append(added, secondaryIndexes...)
+	}
+	added = append(added, searchStatsCollectionName)
+	err := db.Update(func(tx Tx) error {
+		for _, name := range added {
+			if tx.Bucket(name) != nil {
+				if err := tx.DeleteBucket(name); err != nil {
+					return err
+				}
+			}
+		}
+		if version == 0 {
+			return tx.DeleteBucket(metaCollectionName)
+		}
+		return tx.Bucket(metaCollectionName).Put(fSchemaVersion,
+			[]byte(fmt.Sprint(version)))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateNewDatabase(t *testing.T) {
+	s, err := OpenStorage(MemoryBackend, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateDatabase(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckArticleCollection(s); err != nil {
+		t.Fatal(err)
+	}
+	s.View(func(tx Tx) error {
+		if v, _ := schemaVersion(tx); v != migrations[len(migrations)-1].version {
+			t.Errorf("schema version = %d", v)
+		}
+		if c, _ := articleCollection(tx); c == nil || c.Bucket([]byte(RootArticleID)) == nil {
+			t.Error("no root article")
+		}
+		return nil
+	})
+}
+
+func TestMigrateDatabase(t *testing.T) {
+	for version := 0; version < len(migrations); version++ {
+		openTestDatabase(t)
+		a := createArticle(t, RootArticleID, "apple")
+		b := createArticle(t, RootArticleID, "banana")
+		createArticle(t, a.ID, "cherry")
+		a.Content, a.Tags = "see [[banana]]", []string{"fruit"}
+		if err := a.Update(false, false, true, false, true); err != nil {
+			t.Fatal(err)
+		}
+		// an order the children index was built in does not give
+		if err := b.MoveToIndex(0); err != nil {
+			t.Fatal(err)
+		}
+		downgrade(t, version)
+
+		if err := MigrateDatabase(db); err != nil {
+			t.Fatalf("migrating version %d: %v", version, err)
+		}
+		db.View(func(tx Tx) error {
+			if v, _ := schemaVersion(tx); v != migrations[len(migrations)-1].version {
+				t.Errorf("version %d: schema version after migration = %d", version, v)
+			}
+			checkSearchStats(t, tx)
+			return nil
+		})
+		if got := subTitles(t, a.ID); !reflect.DeepEqual(got, []string{"cherry"}) {
+			t.Errorf("version %d: sub-articles = %v", version, got)
+		}
+		got := subTitles(t, RootArticleID)
+		if version >= 2 && !reflect.DeepEqual(got, []string{"banana", "apple"}) {
+			t.Errorf("version %d: order of sub-articles = %v", version, got)
+		} else if len(got) != 2 {
+			t.Errorf("version %d: sub-articles = %v", version, got)
+		}
+		if got, err := GetArticlesByTag("", "fruit"); err != nil || len(got) != 1 || got[0].ID != a.ID {
+			t.Errorf("version %d: articles tagged = %v, %v", version, got, err)
+		}
+		if got := backlinkTitles(t, "", b); !reflect.DeepEqual(got, []string{"apple"}) {
+			t.Errorf("version %d: backlinks = %v", version, got)
+		}
+		if got := searchTitles(t, "cherry"); !reflect.DeepEqual(got, []string{"cherry"}) {
+			t.Errorf("version %d: search = %v", version, got)
+		}
+	}
+}
+
+func TestMigrateNewerDatabase(t *testing.T) {
+	openTestDatabase(t)
+	latest := migrations[len(migrations)-1].version
+	err := db.Update(func(tx Tx) error {
+		return tx.Bucket(metaCollectionName).Put(fSchemaVersion,
+			[]byte(fmt.Sprint(latest+1)))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = MigrateDatabase(db)
+	if e, ok := err.(*SchemaVersionError); !ok || e.Version != latest+1 || e.Supported != latest {
+		t.Errorf("migrating a newer database: %v", err)
+	}
+}
@@ -0,0 +1,776 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// mirror file layout: each article is <name>.md holding its content, its
+// diagram is in <name>.diagram and its sub-articles are in folder <name>/.
+// Names are derived from titles, the root article is at top of folder.
+const (
+	mirrorContentExt = ".md"
+	mirrorDiagramExt = ".diagram"
+	// mirrorStateFile records what was synchronized last time
+	mirrorStateFile = ".notes-mirror.json"
+	// maxMirrorName longer names are truncated, in bytes
+	maxMirrorName = 100
+)
+
+// ErrMirrorEncrypted returned when mirroring an encrypted notebook, whose
+// articles would be written to disk in plain text
+var ErrMirrorEncrypted = errors.New("encrypted notebook can not be mirrored")
+
+// ErrMirrorEmpty returned when the folder of a mirror lost all files, such
+// as when it is unmounted, rather than moving all articles into trash
+var ErrMirrorEmpty = errors.New("mirror folder is empty, remove " +
+	mirrorStateFile + " to mirror into it again")
+
+// Mirror keeps articles in sync with a folder of markdown files, in both
+// directions. A change made on only one side since last sync is copied to
+// the other side. When content or diagram of an article is changed on
+// both sides, the article keeps its version, and the version on disk is
+// kept as a new sibling article. Without a record of last sync, files are
+// matched to articles by path, which keeps them from being imported twice.
+type Mirror struct {
+	mu  sync.Mutex
+	dir string
+	// notebook mirrored
+	notebook Storage
+	// state by article id
+	state map[string]*mirrorEntry
+	// txID id of the last transaction when synchronized
+	txID int
+}
+
+// mirrorEntry an article as it was on both sides after last sync
+type mirrorEntry struct {
+	// Base path of article files without extension, relative to folder
+	// and separated by slash
+	Base       string `json:"base"`
+	ContentMD5 string `json:"contentMD5"`
+	DiagramMD5 string `json:"diagramMD5"`
+	// stamps of files, files whose stamps are unchanged are not read
+	ContentStamp string `json:"contentStamp"`
+	DiagramStamp string `json:"diagramStamp"`
+}
+
+// mirrorNode files on disk of an article
+type mirrorNode struct {
+	base             string
+	content, diagram os.FileInfo
+	dir              bool
+}
+
+// StartMirror synchronize articles of notebook with folder dir, then keep
+// doing it every interval in background, errors there are logged
+func StartMirror(notebook, dir string, interval time.Duration) (*Mirror, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	m := &Mirror{dir: dir, state: make(map[string]*mirrorEntry), txID: -1}
+	var encrypted bool
+	err := UseNotebook(notebook, func() {
+		m.notebook = db
+		encrypted = IsEncrypted()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		return nil, ErrMirrorEncrypted
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(dir, mirrorStateFile)); err == nil {
+		if err := json.Unmarshal(b, &m.state); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", mirrorStateFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err := m.Sync(); err != nil {
+		return nil, err
+	}
+	go func() {
+		for range time.Tick(interval) {
+			if err := m.Sync(); err != nil {
+				log.Println("mirror:", err)
+				if err == ErrMirrorEncrypted {
+					return
+				}
+			}
+		}
+	}()
+	return m, nil
+}
+
+// Sync synchronize articles with files once, it fails with
+// ErrMirrorEncrypted once the notebook is encrypted
+func (m *Mirror) Sync() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return useStorage(m.notebook, m.sync)
+}
+
+func (m *Mirror) sync() error {
+	if IsEncrypted() {
+		return ErrMirrorEncrypted
+	}
+
+	nodes, err := m.scan()
+	if err != nil {
+		return err
+	}
+	txID, err := lastTxID()
+	if err != nil {
+		return err
+	}
+	if txID == m.txID && !m.diskChanged(nodes) {
+		return nil
+	}
+	if len(nodes) == 0 && len(m.state) > 0 {
+		return ErrMirrorEmpty
+	}
+	if len(m.state) == 0 {
+		if err = m.adopt(nodes); err != nil {
+			return err
+		}
+	}
+
+	if err = m.pull(nodes); err != nil {
+		return err
+	}
+	if txID, err = m.push(); err != nil {
+		return err
+	}
+	if err = m.saveState(); err != nil {
+		return err
+	}
+	m.txID = txID
+	return nil
+}
+
+// pull apply changes on disk to articles
+func (m *Mirror) pull(nodes map[string]*mirrorNode) error {
+	articles, _, _, err := mirrorArticles()
+	if err != nil {
+		return err
+	}
+
+	// known articles
+	claimed := make(map[string]bool)
+	var gone, removed []string
+	for _, id := range m.byDepth() {
+		e := m.state[id]
+		n := nodes[e.Base]
+		a := articles[id]
+		switch {
+		case n == nil:
+			// removed on disk, unless the article is changed since
+			if a != nil && id != RootArticleID && !e.articleChanged(a) {
+				gone = append(gone, id)
+			} else {
+				delete(m.state, id)
+			}
+		case a == nil:
+			// removed in notes, unless files are changed since
+			changed, err := m.filesChanged(e, n)
+			if err != nil {
+				return err
+			}
+			delete(m.state, id)
+			if !changed {
+				claimed[e.Base] = true
+				removed = append(removed, e.Base)
+			}
+		default:
+			claimed[e.Base] = true
+			if err = m.merge(a, e, n); err != nil {
+				return err
+			}
+		}
+	}
+	m.removeAll(removed)
+
+	// new files, or known articles moved or renamed on disk
+	ids := make(map[string]string)
+	for id, e := range m.state {
+		ids[e.Base] = id
+	}
+	var added []*mirrorNode
+	for base, n := range nodes {
+		if !claimed[base] {
+			added = append(added, n)
+		}
+	}
+	sort.Slice(added, func(i, j int) bool {
+		return mirrorLess(added[i].base, added[j].base)
+	})
+	for _, n := range added {
+		content, diagram, err := m.read(n)
+		if err != nil {
+			return err
+		}
+		parent, ok := ids[path.Dir(n.base)]
+		if !ok {
+			parent = RootArticleID
+		}
+		title := path.Base(n.base)
+
+		i := -1
+		if n.content != nil {
+			i = movedArticle(gone, m.state, content, diagram)
+		}
+		if i != -1 {
+			a := articles[gone[i]]
+			gone = append(gone[:i], gone[i+1:]...)
+			if mirrorName(a.Title) != title {
+				a.Title = title
+			}
+			if yes, err := a.IsAncestorOf(parent); err != nil || yes {
+				parent = a.Parent
+			}
+			// an article only renamed keeps its position among siblings
+			moved := parent != a.Parent
+			a.Parent = parent
+			if err = a.Update(moved, true, false, false, false); err != nil {
+				return err
+			}
+			m.state[a.ID].Base = n.base
+			ids[n.base] = a.ID
+			continue
+		}
+
+		a := &Article{Parent: parent, Title: title, Content: content,
+			Diagram: diagram}
+		if err = a.Create(); err != nil {
+			return err
+		}
+		// written back in push, moved if its name changes
+		m.state[a.ID] = &mirrorEntry{Base: n.base}
+		ids[n.base] = a.ID
+	}
+
+	// the rest were deleted on disk, parents first
+	sort.Slice(gone, func(i, j int) bool {
+		return mirrorLess(m.state[gone[i]].Base, m.state[gone[j]].Base)
+	})
+	for _, id := range gone {
+		delete(m.state, id)
+		err := (&Article{ID: id}).MoveToTrash()
+		if err != nil && err != ErrArticleNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// adopt match files to articles at the path they would be written to, when
+// nothing is known of last sync. Files which differ are merged as changed
+// on both sides, so that neither version is lost.
+func (m *Mirror) adopt(nodes map[string]*mirrorNode) error {
+	articles, subs, _, err := mirrorArticles()
+	if err != nil {
+		return err
+	}
+	if articles[RootArticleID] == nil {
+		return ErrArticleNotFound
+	}
+	_, bases := mirrorBases(articles, subs)
+	for id, base := range bases {
+		if n := nodes[base]; n != nil && n.content != nil {
+			m.state[id] = &mirrorEntry{Base: base}
+		}
+	}
+	return nil
+}
+
+// merge apply changes of files to article a, the version on disk is kept
+// as a new article if both are changed
+func (m *Mirror) merge(a *Article, e *mirrorEntry, n *mirrorNode) error {
+	if fileStamp(n.content) == e.ContentStamp &&
+		fileStamp(n.diagram) == e.DiagramStamp {
+		return nil
+	}
+	content, diagram, err := m.read(n)
+	if err != nil {
+		return err
+	}
+	contentMD5, diagramMD5 := (&Article{Content: content, Diagram: diagram}).MD5()
+	oldContentMD5, oldDiagramMD5 := a.MD5()
+
+	var uContent, uDiagram, conflict bool
+	if n.content != nil && contentMD5 != e.ContentMD5 {
+		if oldContentMD5 == e.ContentMD5 {
+			a.Content, uContent = content, true
+		} else if oldContentMD5 != contentMD5 {
+			conflict = true
+		}
+	}
+	if diagramMD5 != e.DiagramMD5 {
+		if oldDiagramMD5 == e.DiagramMD5 {
+			a.Diagram, uDiagram = diagram, true
+		} else if oldDiagramMD5 != diagramMD5 {
+			conflict = true
+		}
+	}
+
+	if uContent || uDiagram {
+		if err = a.Update(false, false, uContent, uDiagram, false); err != nil {
+			return err
+		}
+	}
+	if conflict {
+		c := &Article{
+			Parent: a.Parent,
+			Title: fmt.Sprintf("%s (conflict %s)", a.Title,
+				time.Now().Format("2006-01-02 15.04.05")),
+			Content: content,
+			Diagram: diagram,
+		}
+		if err = c.Create(); err != nil {
+			return err
+		}
+		log.Printf("mirror: %s was changed on both sides, "+
+			"the version on disk is kept as %s", e.Base, c.ID)
+	}
+	return nil
+}
+
+// push write articles to files, moving files of articles renamed or
+// moved in notes. Returns id of the transaction articles were read in.
+func (m *Mirror) push() (int, error) {
+	articles, subs, txID, err := mirrorArticles()
+	if err != nil {
+		return 0, err
+	}
+
+	if articles[RootArticleID] == nil {
+		return 0, ErrArticleNotFound
+	}
+	order, bases := mirrorBases(articles, subs)
+
+	for _, id := range order {
+		a, base := articles[id], bases[id]
+		e := m.state[id]
+		if e == nil {
+			m.clear(base, "")
+			e = &mirrorEntry{Base: base}
+			m.state[id] = e
+		}
+		if e.Base != base {
+			m.move(id, base)
+		}
+
+		contentMD5, diagramMD5 := a.MD5()
+		file := m.file(base + mirrorContentExt)
+		if _, err := os.Stat(file); err != nil || e.ContentMD5 != contentMD5 {
+			if err = m.write(file, a.Content); err != nil {
+				return 0, err
+			}
+		}
+		file = m.file(base + mirrorDiagramExt)
+		_, err := os.Stat(file)
+		switch {
+		case a.Diagram == "" && err == nil:
+			if err = os.Remove(file); err != nil {
+				return 0, err
+			}
+		case a.Diagram != "" && (err != nil || e.DiagramMD5 != diagramMD5):
+			if err = m.write(file, a.Diagram); err != nil {
+				return 0, err
+			}
+		}
+		if len(subs[id]) > 0 {
+			if err = os.MkdirAll(m.file(base), 0755); err != nil {
+				return 0, err
+			}
+		} else {
+			// left empty by sub-articles moved away, fails if not empty
+			os.Remove(m.file(base))
+		}
+
+		e.ContentMD5, e.DiagramMD5 = contentMD5, diagramMD5
+		e.ContentStamp = stamp(m.file(base + mirrorContentExt))
+		e.DiagramStamp = stamp(m.file(base + mirrorDiagramExt))
+	}
+
+	// articles no longer in the tree, such as moved into trash
+	var removed []string
+	for id, e := range m.state {
+		if _, ok := bases[id]; !ok {
+			delete(m.state, id)
+			removed = append(removed, e.Base)
+		}
+	}
+	m.removeAll(removed)
+	return txID, nil
+}
+
+// move rename files of article id and its folder to base path to, see
+// clear
+func (m *Mirror) move(id, to string) {
+	m.clear(to, m.state[id].Base)
+	from := m.state[id].Base
+	m.rename(from, to)
+	m.state[id].Base = to
+	m.rebase(from, to)
+}
+
+// clear move files at base path aside, unless they are at base path self.
+// They are of an article, such as a sibling whose name is taken by
+// another, which moves again when its turn comes, or are imported as a
+// new article next time.
+func (m *Mirror) clear(base, self string) {
+	if !m.taken(base, self) {
+		return
+	}
+	aside := base
+	for k := 1; m.taken(aside, ""); k++ {
+		aside = fmt.Sprintf("%s (moving %d)", base, k)
+	}
+	m.rename(base, aside)
+	for _, e := range m.state {
+		if e.Base == base {
+			e.Base = aside
+		}
+	}
+	m.rebase(base, aside)
+}
+
+// rename files at base path from and its folder to base path to
+func (m *Mirror) rename(from, to string) {
+	if err := os.MkdirAll(m.file(path.Dir(to)), 0755); err != nil {
+		log.Println("mirror:", err)
+		return
+	}
+	for _, ext := range []string{mirrorContentExt, mirrorDiagramExt, ""} {
+		err := os.Rename(m.file(from+ext), m.file(to+ext))
+		if err != nil && !os.IsNotExist(err) {
+			log.Println("mirror:", err)
+		}
+	}
+}
+
+// rebase update bases of known articles in folder from, which is moved to
+// folder to
+func (m *Mirror) rebase(from, to string) {
+	for _, e := range m.state {
+		if strings.HasPrefix(e.Base, from+"/") {
+			e.Base = to + strings.TrimPrefix(e.Base, from)
+		}
+	}
+}
+
+// taken check whether any file exists at base path, other than files at
+// base path self, which may differ only in case
+func (m *Mirror) taken(base, self string) bool {
+	for _, ext := range []string{mirrorContentExt, mirrorDiagramExt, ""} {
+		info, err := os.Stat(m.file(base + ext))
+		if err != nil {
+			continue
+		}
+		if self != "" {
+			other, err := os.Stat(m.file(self + ext))
+			if err == nil && os.SameFile(info, other) {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// removeAll delete files of articles, their folders are deleted if they
+// become empty
+func (m *Mirror) removeAll(bases []string) {
+	// sub-articles first, so that folders are empty
+	sort.Slice(bases, func(i, j int) bool { return mirrorLess(bases[j], bases[i]) })
+	for _, base := range bases {
+		for _, ext := range []string{mirrorContentExt, mirrorDiagramExt, ""} {
+			err := os.Remove(m.file(base + ext))
+			if err != nil && !os.IsNotExist(err) && ext != "" {
+				log.Println("mirror:", err)
+			}
+		}
+	}
+}
+
+// scan find files of articles, by base path
+func (m *Mirror) scan() (map[string]*mirrorNode, error) {
+	nodes := make(map[string]*mirrorNode)
+	node := func(base string) *mirrorNode {
+		if nodes[base] == nil {
+			nodes[base] = &mirrorNode{base: base}
+		}
+		return nodes[base]
+	}
+	err := filepath.Walk(m.dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(m.dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case info.IsDir():
+			node(rel).dir = true
+		case strings.HasSuffix(rel, mirrorContentExt):
+			node(strings.TrimSuffix(rel, mirrorContentExt)).content = info
+		case strings.HasSuffix(rel, mirrorDiagramExt):
+			node(strings.TrimSuffix(rel, mirrorDiagramExt)).diagram = info
+		}
+		return nil
+	})
+	return nodes, err
+}
+
+// diskChanged check whether files are changed since last sync
+func (m *Mirror) diskChanged(nodes map[string]*mirrorNode) bool {
+	known := 0
+	for _, e := range m.state {
+		n := nodes[e.Base]
+		if n == nil || fileStamp(n.content) != e.ContentStamp ||
+			fileStamp(n.diagram) != e.DiagramStamp {
+			return true
+		}
+		known++
+	}
+	return known != len(nodes)
+}
+
+// filesChanged check whether content or diagram of n are changed since
+// last sync
+func (m *Mirror) filesChanged(e *mirrorEntry, n *mirrorNode) (bool, error) {
+	if fileStamp(n.content) == e.ContentStamp &&
+		fileStamp(n.diagram) == e.DiagramStamp {
+		return false, nil
+	}
+	content, diagram, err := m.read(n)
+	if err != nil {
+		return false, err
+	}
+	contentMD5, diagramMD5 := (&Article{Content: content, Diagram: diagram}).MD5()
+	return (n.content != nil && contentMD5 != e.ContentMD5) ||
+		diagramMD5 != e.DiagramMD5, nil
+}
+
+// read content and diagram of n, a missing file is read as empty
+func (m *Mirror) read(n *mirrorNode) (content, diagram string, err error) {
+	if n.content != nil {
+		b, err := ioutil.ReadFile(m.file(n.base + mirrorContentExt))
+		if err != nil {
+			return "", "", err
+		}
+		content = string(b)
+	}
+	if n.diagram != nil {
+		b, err := ioutil.ReadFile(m.file(n.base + mirrorDiagramExt))
+		if err != nil {
+			return "", "", err
+		}
+		diagram = string(b)
+	}
+	return
+}
+
+func (m *Mirror) write(file, data string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, []byte(data), 0644)
+}
+
+func (m *Mirror) saveState() error {
+	b, err := json.MarshalIndent(m.state, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(m.dir, mirrorStateFile+".tmp")
+	if err = ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(m.dir, mirrorStateFile))
+}
+
+// file return path of file at base path
+func (m *Mirror) file(base string) string {
+	return filepath.Join(m.dir, filepath.FromSlash(base))
+}
+
+// byDepth return ids of known articles, parents first
+func (m *Mirror) byDepth() []string {
+	ids := make([]string, 0, len(m.state))
+	for id := range m.state {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return mirrorLess(m.state[ids[i]].Base, m.state[ids[j]].Base)
+	})
+	return ids
+}
+
+// articleChanged check whether content or diagram of a are changed since
+// last sync
+func (e *mirrorEntry) articleChanged(a *Article) bool {
+	contentMD5, diagramMD5 := a.MD5()
+	return contentMD5 != e.ContentMD5 || diagramMD5 != e.DiagramMD5
+}
+
+// movedArticle find an article removed on disk whose files have content
+// and diagram, returns its index in gone or -1
+func movedArticle(gone []string, state map[string]*mirrorEntry,
+	content, diagram string) int {
+	contentMD5, diagramMD5 := (&Article{Content: content, Diagram: diagram}).MD5()
+	for i, id := range gone {
+		if e := state[id]; e.ContentMD5 == contentMD5 &&
+			e.DiagramMD5 == diagramMD5 {
+			return i
+		}
+	}
+	return -1
+}
+
+// mirrorArticles load all articles, and ids of sub-articles in order,
+// with id of the transaction they are read in. Private articles and their
+// descendants are never mirrored, whether locked or not.
+func mirrorArticles() (articles map[string]*Article, subs map[string][]string,
+	txID int, err error) {
+	articles = make(map[string]*Article)
+	subs = make(map[string][]string)
+	err = db.View(func(tx Tx) error {
+		txID = tx.ID()
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		pv := newPrivacy(c, "")
+		return c.ForEach(func(k, _ []byte) error {
+			b := c.Bucket(k)
+			id := string(k)
+			if pv.hidden(id) {
+				return nil
+			}
+			articles[id] = &Article{
+				ID:        id,
+				Parent:    string(b.Get(fParent)),
+				Title:     string(b.Get(fTitle)),
+				Content:   string(b.Get(fContent)),
+				Diagram:   string(b.Get(fDiagram)),
+				CreatedAt: parseTime(b.Get(fCreatedAt)),
+				UpdatedAt: parseTime(b.Get(fUpdatedAt)),
+				Tags:      decodeTags(b.Get(fTags)),
+			}
+			for _, sub := range childrenOf(tx, id) {
+				if !pv.hidden(sub) {
+					subs[id] = append(subs[id], sub)
+				}
+			}
+			return nil
+		})
+	})
+	return
+}
+
+func lastTxID() (id int, err error) {
+	err = db.View(func(tx Tx) error {
+		id = tx.ID()
+		return nil
+	})
+	return
+}
+
+// mirrorBases return base paths articles are written to, and their ids
+// parents first
+func mirrorBases(articles map[string]*Article, subs map[string][]string) (
+	order []string, bases map[string]string) {
+	order = []string{RootArticleID}
+	bases = map[string]string{RootArticleID: mirrorName(articles[RootArticleID].Title)}
+	for i := 0; i < len(order); i++ {
+		taken := make(map[string]bool)
+		for _, sub := range subs[order[i]] {
+			a := articles[sub]
+			if a == nil || bases[sub] != "" {
+				continue
+			}
+			name := mirrorName(a.Title)
+			for k := 2; taken[strings.ToLower(name)]; k++ {
+				name = fmt.Sprintf("%s (%d)", mirrorName(a.Title), k)
+			}
+			taken[strings.ToLower(name)] = true
+			bases[sub] = bases[order[i]] + "/" + name
+			order = append(order, sub)
+		}
+	}
+	return
+}
+
+// mirrorName return file name for an article title
+func mirrorName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '-'
+		}
+		return r
+	}, title)
+	if len(name) > maxMirrorName {
+		n := maxMirrorName
+		for n > 0 && !utf8.RuneStart(name[n]) {
+			n--
+		}
+		name = name[:n]
+	}
+	// no hidden files, and no names taken for diagrams of siblings
+	name = strings.Trim(name, " .")
+	if strings.HasSuffix(strings.ToLower(name), mirrorDiagramExt) {
+		name += "_"
+	}
+	if name == "" {
+		name = "Untitled"
+	}
+	return name
+}
+
+// mirrorLess order base paths parents first
+func mirrorLess(x, y string) bool {
+	dx, dy := strings.Count(x, "/"), strings.Count(y, "/")
+	if dx != dy {
+		return dx < dy
+	}
+	return x < y
+}
+
+// stamp return stamp of a file, empty if it does not exist
+func stamp(file string) string {
+	info, err := os.Stat(file)
+	if err != nil {
+		return ""
+	}
+	return fileStamp(info)
+}
+
+func fileStamp(info os.FileInfo) string {
+	if info == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", info.Size(), info.ModTime().UnixNano())
+}
@@ -0,0 +1,136 @@
+package resources
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startMirror mirror a new notebook with articles titled titles under
+// root, into a new folder
+func startMirror(t *testing.T, titles ...string) (*Mirror, string, []*Article) {
+	openTestDatabase(t)
+	var articles []*Article
+	for _, title := range titles {
+		articles = append(articles, createArticle(t, RootArticleID, title))
+	}
+	dir := t.TempDir()
+	m, err := StartMirror(DefaultNotebook, dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, dir, articles
+}
+
+// mirrorFiles content of markdown files in dir, by path
+func mirrorFiles(t *testing.T, dir string) map[string]string {
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !strings.HasSuffix(p, mirrorContentExt) {
+			return err
+		}
+		b, err := ioutil.ReadFile(p)
+		rel, _ := filepath.Rel(dir, p)
+		files[filepath.ToSlash(rel)] = string(b)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestMirrorRenameCollisions(t *testing.T) {
+	tests := []struct {
+		name   string
+		titles []string
+		want   map[string]string
+	}{
+		{"rename onto a sibling", []string{"b", "b"}, map[string]string{
+			"First Article/b.md":     "a content",
+			"First Article/b (2).md": "b content",
+		}},
+		{"swap", []string{"b", "a"}, map[string]string{
+			"First Article/b.md": "a content",
+			"First Article/a.md": "b content",
+		}},
+	}
+	for _, tt := range tests {
+		m, dir, articles := startMirror(t, "a", "b")
+		for i, a := range articles {
+			a.Title = tt.titles[i]
+			if err := a.Update(false, true, false, false, false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := m.Sync(); err != nil {
+			t.Fatal(err)
+		}
+		got := mirrorFiles(t, dir)
+		delete(got, "First Article.md")
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: files = %v, want %v", tt.name, got, tt.want)
+		}
+
+		// articles are left as they are
+		if err := m.Sync(); err != nil {
+			t.Fatal(err)
+		}
+		if got := subTitles(t, RootArticleID); !reflect.DeepEqual(got, tt.titles) {
+			t.Errorf("%s: sub-articles = %v, want %v", tt.name, got, tt.titles)
+		}
+		bases := make(map[string]bool)
+		for _, e := range m.state {
+			if bases[e.Base] {
+				t.Errorf("%s: two articles at %s", tt.name, e.Base)
+			}
+			bases[e.Base] = true
+		}
+	}
+}
+
+func TestMirrorLostState(t *testing.T) {
+	_, dir, _ := startMirror(t, "a", "b")
+	if err := os.Remove(filepath.Join(dir, mirrorStateFile)); err != nil {
+		t.Fatal(err)
+	}
+	err := ioutil.WriteFile(filepath.Join(dir, "First Article", "b.md"),
+		[]byte("changed"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// files are matched by path, a file which differs is kept as a conflict
+	if _, err := StartMirror(DefaultNotebook, dir, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	got := subTitles(t, RootArticleID)
+	if len(got) != 3 || got[0] != "a" || got[1] != "b" ||
+		!strings.HasPrefix(got[2], "b (conflict ") {
+		t.Errorf("sub-articles = %v", got)
+	}
+}
+
+func TestMirrorEmptyFolder(t *testing.T) {
+	m, dir, _ := startMirror(t, "a", "b")
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if err := os.RemoveAll(filepath.Join(dir, f.Name())); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := m.Sync(); err != ErrMirrorEmpty {
+		t.Errorf("Sync of an empty folder: %v", err)
+	}
+	if got, want := subTitles(t, RootArticleID), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sub-articles = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,297 @@
+package resources
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultNotebook name of the notebook created when there is none, kept
+// in notes.db as all notes were before notebooks
+const DefaultNotebook = "notes"
+
+// notebookExt extension of notebook files in data folder
+const notebookExt = ".db"
+
+// notebook errors
+var (
+	ErrNotebookNotFound    = errors.New("notebook not found")
+	ErrNotebookExists      = errors.New("notebook already exists")
+	ErrInvalidNotebookName = errors.New("invalid notebook name, " +
+		"use letters, digits, spaces, '-' and '_'")
+)
+
+var notebookNameRegexp = regexp.MustCompile(`^[\pL\pN_-][\pL\pN _-]{0,63}$`)
+
+// Notebook brief of a notebook
+type Notebook struct {
+	Name      string `json:"name"`
+	Current   bool   `json:"current"`
+	Encrypted bool   `json:"encrypted"`
+	Locked    bool   `json:"locked"`
+}
+
+// notebooks open notebooks, each a database of its own. Resources work on
+// db, which is set to a notebook while mu is held, see UseNotebook. Calls
+// reading the notebook in db share mu, while changes and switches of db to
+// another notebook hold it alone.
+var notebooks struct {
+	mu              sync.RWMutex
+	backend, folder string
+	open            map[string]*cryptStorage
+	// current notebook used when none is named
+	current string
+}
+
+// OpenDatabase must be called before any other models' operations.
+// It opens all notebooks in folder, one is created if there is none.
+// backend is BoltBackend or MemoryBackend, which keeps only the notebooks
+// created since. Encrypted notebooks are unlocked with passphrase, they
+// stay locked if passphrase is empty or wrong until Unlock is called.
+func OpenDatabase(backend, folder, passphrase string) error {
+	notebooks.mu.Lock()
+	defer notebooks.mu.Unlock()
+	notebooks.backend, notebooks.folder = backend, folder
+	notebooks.open = make(map[string]*cryptStorage)
+	notebooks.current = DefaultNotebook
+
+	var names []string
+	if backend != MemoryBackend {
+		files, err := ioutil.ReadDir(folder)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			name := strings.TrimSuffix(f.Name(), notebookExt)
+			if !f.IsDir() && strings.HasSuffix(f.Name(), notebookExt) &&
+				notebookNameRegexp.MatchString(name) {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		names = []string{DefaultNotebook}
+	} else if notebookIndex(names, DefaultNotebook) == -1 {
+		notebooks.current = names[0]
+	}
+
+	for _, name := range names {
+		s, err := openNotebook(name)
+		if err != nil {
+			return err
+		}
+		notebooks.open[name] = s
+		if err = unlock(s, passphrase); err != nil && err != ErrLocked {
+			log.Printf("notebook %s stays locked: %v", name, err)
+		}
+	}
+	db = notebooks.open[notebooks.current]
+	return nil
+}
+
+// openNotebook open storage of notebook name, upgrading it unless it is
+// encrypted
+func openNotebook(name string) (*cryptStorage, error) {
+	raw, err := OpenStorage(notebooks.backend, notebookFile(name))
+	if err != nil {
+		return nil, err
+	}
+	s, err := newCryptStorage(raw)
+	if err == nil && !s.encrypted {
+		err = MigrateDatabase(s)
+	}
+	if err != nil {
+		raw.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func notebookFile(name string) string {
+	return filepath.Join(notebooks.folder, name+notebookExt)
+}
+
+func notebookIndex(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// UseNotebook run fn with notebook name in use, or the current notebook
+// if name is empty. fn must only read the notebook, it runs along with
+// other readers of the same notebook.
+func UseNotebook(name string, fn func()) error {
+	return useNotebook(name, false, fn)
+}
+
+// ChangeNotebook run fn with notebook name in use like UseNotebook, fn may
+// change the notebook and runs alone.
+func ChangeNotebook(name string, fn func()) error {
+	return useNotebook(name, true, fn)
+}
+
+func useNotebook(name string, exclusive bool, fn func()) error {
+	for {
+		if !exclusive {
+			notebooks.mu.RLock()
+			s, err := notebookNamed(name)
+			if err == nil && db == s {
+				defer notebooks.mu.RUnlock()
+				fn()
+				return nil
+			}
+			notebooks.mu.RUnlock()
+			if err != nil {
+				return err
+			}
+		}
+
+		// switch db while no one reads it
+		notebooks.mu.Lock()
+		s, err := notebookNamed(name)
+		if err != nil {
+			notebooks.mu.Unlock()
+			return err
+		}
+		db = s
+		if exclusive {
+			defer notebooks.mu.Unlock()
+			fn()
+			return nil
+		}
+		notebooks.mu.Unlock()
+	}
+}
+
+// notebookNamed return notebook name, or the current notebook if name is
+// empty, mu must be held
+func notebookNamed(name string) (*cryptStorage, error) {
+	if name == "" {
+		name = notebooks.current
+	}
+	s := notebooks.open[name]
+	if s == nil {
+		return nil, ErrNotebookNotFound
+	}
+	return s, nil
+}
+
+// NotebookInUse return name of the notebook in use, it must be called
+// within UseNotebook or ChangeNotebook
+func NotebookInUse() string {
+	for name, s := range notebooks.open {
+		if s == db {
+			return name
+		}
+	}
+	return ""
+}
+
+// useStorage run fn alone with notebook s in use, s is kept when it is
+// renamed
+func useStorage(s Storage, fn func() error) error {
+	notebooks.mu.Lock()
+	defer notebooks.mu.Unlock()
+	db = s
+	return fn()
+}
+
+// ListNotebooks list notebooks by name
+func ListNotebooks() []*Notebook {
+	notebooks.mu.RLock()
+	defer notebooks.mu.RUnlock()
+	var list []*Notebook
+	for name, s := range notebooks.open {
+		encrypted, locked := s.state()
+		list = append(list, &Notebook{
+			Name:      name,
+			Current:   name == notebooks.current,
+			Encrypted: encrypted,
+			Locked:    locked,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+	})
+	return list
+}
+
+// CreateNotebook create an empty notebook
+func CreateNotebook(name string) error {
+	notebooks.mu.Lock()
+	defer notebooks.mu.Unlock()
+	if err := checkNewNotebook(name); err != nil {
+		return err
+	}
+	s, err := openNotebook(name)
+	if err != nil {
+		return err
+	}
+	notebooks.open[name] = s
+	return nil
+}
+
+// RenameNotebook rename a notebook, along with its file
+func RenameNotebook(from, to string) error {
+	notebooks.mu.Lock()
+	defer notebooks.mu.Unlock()
+	s := notebooks.open[from]
+	if s == nil {
+		return ErrNotebookNotFound
+	}
+	if err := checkNewNotebook(to); err != nil {
+		return err
+	}
+	if b, ok := s.raw.(*boltStorage); ok {
+		if err := b.rename(notebookFile(to)); err != nil {
+			return err
+		}
+	}
+	delete(notebooks.open, from)
+	notebooks.open[to] = s
+	if notebooks.current == from {
+		notebooks.current = to
+	}
+	return nil
+}
+
+// SwitchNotebook make notebook name the current one
+func SwitchNotebook(name string) error {
+	notebooks.mu.Lock()
+	defer notebooks.mu.Unlock()
+	if notebooks.open[name] == nil {
+		return ErrNotebookNotFound
+	}
+	notebooks.current = name
+	return nil
+}
+
+// checkNewNotebook check name for a new notebook, mu must be held
+func checkNewNotebook(name string) error {
+	if !notebookNameRegexp.MatchString(name) {
+		return ErrInvalidNotebookName
+	}
+	for n := range notebooks.open {
+		// file names may be case insensitive
+		if strings.EqualFold(n, name) {
+			return ErrNotebookExists
+		}
+	}
+	if notebooks.backend != MemoryBackend {
+		if _, err := os.Stat(notebookFile(name)); err == nil {
+			return ErrNotebookExists
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
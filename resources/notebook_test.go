@@ -0,0 +1,51 @@
+package resources
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUseNotebook(t *testing.T) {
+	openTestDatabase(t)
+	if err := CreateNotebook("other"); err != nil {
+		t.Fatal(err)
+	}
+
+	// readers of the notebook in use run together
+	entered := make(chan bool)
+	done := make(chan error)
+	go func() {
+		done <- UseNotebook("", func() { <-entered })
+	}()
+	err := UseNotebook("", func() {
+		select {
+		case entered <- true:
+		case <-time.After(5 * time.Second):
+			t.Error("readers of a notebook run one at a time")
+		}
+	})
+	if err != nil || <-done != nil {
+		t.Fatal(err)
+	}
+
+	// a switch waits for readers, which see their own notebook
+	var current, other string
+	go func() {
+		done <- UseNotebook("", func() {
+			<-entered
+			current = NotebookInUse()
+		})
+	}()
+	entered <- true
+	ChangeNotebook("other", func() { other = NotebookInUse() })
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if current != DefaultNotebook || other != "other" {
+		t.Errorf("notebooks in use = %q, %q", current, other)
+	}
+
+	if err := UseNotebook("none", func() {}); err != ErrNotebookNotFound {
+		t.Errorf("UseNotebook(none) = %v", err)
+	}
+}
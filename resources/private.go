@@ -0,0 +1,269 @@
+package resources
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"sync"
+	"time"
+)
+
+// fPrivate article field of a private article, holding salt and key derived
+// from its password
+var fPrivate = []byte("Private")
+
+const (
+	privateSaltSize   = 16
+	privateKeySize    = 32
+	privateIterations = 100000
+)
+
+// private article errors
+var (
+	ErrWrongPassword = errors.New("wrong password")
+	ErrEmptyPassword = errors.New("empty password")
+	ErrNotPrivate    = errors.New("article is not private")
+)
+
+// PrivateError returned for an article hidden by a locked private article
+type PrivateError struct {
+	Message string `json:"error"`
+	// Article id of the private article to unlock
+	Article string `json:"private"`
+	Title   string `json:"title"`
+}
+
+func (e *PrivateError) Error() string {
+	return e.Message
+}
+
+// unlockKey a private article unlocked in a notebook for a session, which
+// is a token given to a client
+type unlockKey struct {
+	notebook Storage
+	session  string
+	id       string
+}
+
+// unlockedPrivate private articles unlocked, to when they lock again
+var unlockedPrivate = struct {
+	sync.Mutex
+	until map[unlockKey]time.Time
+}{until: make(map[unlockKey]time.Time)}
+
+// isUnlocked tell whether private article id of the notebook in use is
+// unlocked for session, no article is for an empty session
+func isUnlocked(session, id string) bool {
+	if session == "" {
+		return false
+	}
+	unlockedPrivate.Lock()
+	defer unlockedPrivate.Unlock()
+	k := unlockKey{db, session, id}
+	until, ok := unlockedPrivate.until[k]
+	if ok && time.Now().After(until) {
+		delete(unlockedPrivate.until, k)
+		return false
+	}
+	return ok
+}
+
+// SetPrivate make article private with password, or change its password.
+// Its content, diagram and descendants are hidden while it is locked,
+// it is locked until unlocked with password.
+func (a *Article) SetPrivate(password string) error {
+	if password == "" {
+		return ErrEmptyPassword
+	}
+	salt := make([]byte, privateSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	key, err := privateKey(password, salt)
+	if err != nil {
+		return err
+	}
+
+	return db.Update(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		b := c.Bucket([]byte(a.ID))
+		if b == nil {
+			return ErrArticleNotFound
+		}
+		if err = saveRevision(tx, a.ID, b); err != nil {
+			return err
+		}
+		a.Private = true
+		if err = b.Put(fPrivate, append(salt, key...)); err != nil {
+			return err
+		}
+		a.Version, err = bumpVersion(b)
+		return err
+	})
+}
+
+// ClearPrivate make a private article public again
+func (a *Article) ClearPrivate() error {
+	return db.Update(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		b := c.Bucket([]byte(a.ID))
+		if b == nil {
+			return ErrArticleNotFound
+		}
+		if b.Get(fPrivate) == nil {
+			return ErrNotPrivate
+		}
+		if err = saveRevision(tx, a.ID, b); err != nil {
+			return err
+		}
+		a.Private = false
+		if err = b.Delete(fPrivate); err != nil {
+			return err
+		}
+		a.Version, err = bumpVersion(b)
+		return err
+	})
+}
+
+// UnlockPrivate unlock private article id with its password for session,
+// it locks again after timeout
+func UnlockPrivate(session, id, password string, timeout time.Duration) error {
+	if session == "" {
+		return errors.New("no session to unlock the article for")
+	}
+	var stored []byte
+	err := db.View(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		b := c.Bucket([]byte(id))
+		if b == nil {
+			return ErrArticleNotFound
+		}
+		stored = append(stored, b.Get(fPrivate)...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(stored) != privateSaltSize+privateKeySize {
+		return ErrNotPrivate
+	}
+
+	key, err := privateKey(password, stored[:privateSaltSize])
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(key, stored[privateSaltSize:]) != 1 {
+		return ErrWrongPassword
+	}
+
+	now := time.Now()
+	unlockedPrivate.Lock()
+	defer unlockedPrivate.Unlock()
+	for k, until := range unlockedPrivate.until {
+		if now.After(until) {
+			delete(unlockedPrivate.until, k)
+		}
+	}
+	unlockedPrivate.until[unlockKey{db, session, id}] = now.Add(timeout)
+	return nil
+}
+
+// LockPrivate lock all private articles unlocked for session now, in all
+// notebooks
+func LockPrivate(session string) {
+	unlockedPrivate.Lock()
+	defer unlockedPrivate.Unlock()
+	for k := range unlockedPrivate.until {
+		if k.session == session {
+			delete(unlockedPrivate.until, k)
+		}
+	}
+}
+
+// CheckUnlocked returns a *PrivateError if article id is a private article
+// locked for session, or a descendant of one
+func CheckUnlocked(session, id string) error {
+	return db.View(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		p := newPrivacy(c, session).by(id)
+		if p == "" {
+			return nil
+		}
+		return &PrivateError{
+			Message: "article is private, unlock it with its password",
+			Article: p,
+			Title:   string(c.Bucket([]byte(p)).Get(fTitle)),
+		}
+	})
+}
+
+func privateKey(password string, salt []byte) ([]byte, error) {
+	if password == "" {
+		return nil, ErrEmptyPassword
+	}
+	return pbkdf2.Key(sha256.New, password, salt, privateIterations,
+		privateKeySize)
+}
+
+// privacy find private articles hiding others from a session, within a
+// transaction
+type privacy struct {
+	c Bucket
+	// session private articles unlocked for it hide nothing, all private
+	// articles hide others if it is empty
+	session string
+	// hiders of articles already looked up
+	hiders map[string]string
+}
+
+func newPrivacy(c Bucket, session string) *privacy {
+	return &privacy{c: c, session: session, hiders: make(map[string]string)}
+}
+
+// by return the nearest private article among id and its ancestors,
+// empty if there is none
+func (p *privacy) by(id string) string {
+	var path []string
+	hider := ""
+	seen := make(map[string]bool)
+	for id != "" && !seen[id] {
+		if h, ok := p.hiders[id]; ok {
+			hider = h
+			break
+		}
+		seen[id] = true
+		path = append(path, id)
+		b := p.c.Bucket([]byte(id))
+		if b == nil {
+			break
+		}
+		if b.Get(fPrivate) != nil && !isUnlocked(p.session, id) {
+			hider = id
+			break
+		}
+		id = string(b.Get(fParent))
+	}
+	for _, id := range path {
+		p.hiders[id] = hider
+	}
+	return hider
+}
+
+// hidden tell whether article id is a private article or inside one
+func (p *privacy) hidden(id string) bool {
+	return p.by(id) != ""
+}
@@ -0,0 +1,73 @@
+package resources
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPrivateSessions(t *testing.T) {
+	openTestDatabase(t)
+	a := createArticle(t, RootArticleID, "a")
+	sub := createArticle(t, a.ID, "sub")
+	if err := a.SetPrivate("password"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := CheckUnlocked("s1", sub.ID).(*PrivateError); !ok {
+		t.Error("sub-article of a locked private article is not hidden")
+	}
+	if err := UnlockPrivate("s1", a.ID, "wrong", time.Minute); err != ErrWrongPassword {
+		t.Errorf("unlock with a wrong password: %v", err)
+	}
+	if err := UnlockPrivate("s1", a.ID, "password", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckUnlocked("s1", sub.ID); err != nil {
+		t.Errorf("unlocked for s1: %v", err)
+	}
+	if err := CheckUnlocked("s2", sub.ID); err == nil {
+		t.Error("unlocked for another session")
+	}
+	LockPrivate("s1")
+	if err := CheckUnlocked("s1", sub.ID); err == nil {
+		t.Error("unlocked after LockPrivate")
+	}
+}
+
+func TestPrivateTrash(t *testing.T) {
+	openTestDatabase(t)
+	a := createArticle(t, RootArticleID, "a")
+	sub := createArticle(t, a.ID, "sub")
+	if err := a.SetPrivate("password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.MoveToTrash(); err != nil {
+		t.Fatal(err)
+	}
+
+	// hidden while a is locked
+	if items, _ := ListTrash("s1"); len(items) != 0 {
+		t.Errorf("trash of a locked session = %+v", items)
+	}
+	if _, err := GetTrashItem("s1", sub.ID); err != ErrTrashItemNotFound {
+		t.Errorf("GetTrashItem of a hidden item: %v", err)
+	}
+	if err := PurgeTrash("s1", sub.ID); err != ErrTrashItemNotFound {
+		t.Errorf("PurgeTrash of a hidden item: %v", err)
+	}
+	if err := PurgeTrashBefore("s1", time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UnlockPrivate("s1", a.ID, "password", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if item, err := GetTrashItem("s1", sub.ID); err != nil || item.Title != "sub" {
+		t.Fatalf("GetTrashItem unlocked = %+v, %v", item, err)
+	}
+	if err := PurgeTrash("s1", sub.ID); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := ListTrash("s1"); len(items) != 0 {
+		t.Errorf("trash after purge = %+v", items)
+	}
+}
@@ -0,0 +1,351 @@
+package resources
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// types of property values
+const (
+	PropertyString = "string"
+	PropertyNumber = "number"
+	PropertyBool   = "bool"
+	PropertyDate   = "date"
+)
+
+// frontMatterDelimiter starts and ends the front-matter block of properties
+// at the top of content, in YAML
+const frontMatterDelimiter = "---"
+
+// propertyLineRegexp matches a line of front-matter: name: value
+var propertyLineRegexp = regexp.MustCompile(`^([\pL\pN_.-]+)[ \t]*:(?:[ \t](.*))?$`)
+
+// Property a typed value of an article property
+type Property struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+
+	// for comparison
+	number float64
+	date   time.Time
+}
+
+// PropertyFilter a condition on a property: Name Op Value, or that the
+// property exists if Op is empty
+type PropertyFilter struct {
+	Name, Op string
+	Value    *Property
+}
+
+// PropertyHit an article matched by a property query
+type PropertyHit struct {
+	*ArticleTitle
+	Properties map[string]*Property `json:"properties"`
+}
+
+// PropertyResult a page of articles matched by a property query
+type PropertyResult struct {
+	Total int            `json:"total"`
+	Hits  []*PropertyHit `json:"hits"`
+}
+
+// property filter operators, longer ones first for parsing
+var propertyOps = []string{"!=", "<=", ">=", "=", "<", ">"}
+
+// Properties properties of article, from front-matter of its content
+func (a *Article) Properties() map[string]*Property {
+	props, _ := ParseProperties(a.Content)
+	return props
+}
+
+// ParseProperties parse the front-matter block at the top of content,
+// returning properties and the content after the block. Properties are
+// written one per line as "name: value", values are numbers, true or
+// false, dates as 2006-01-02 or in RFC 3339, or strings, which may be
+// quoted. Besides them only blank lines and comments are allowed, else
+// the block is not front-matter, content may begin with a rule.
+func ParseProperties(content string) (map[string]*Property, string) {
+	text := strings.Replace(content, "\r\n", "\n", -1)
+	if !strings.HasPrefix(text, frontMatterDelimiter+"\n") {
+		return nil, content
+	}
+	lines := strings.Split(text, "\n")
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if l := strings.TrimRight(lines[i], " \t"); l == frontMatterDelimiter ||
+			l == "..." {
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		return nil, content
+	}
+
+	props := make(map[string]*Property)
+	for _, l := range lines[1:end] {
+		if strings.TrimSpace(l) == "" || l[0] == '#' {
+			continue
+		}
+		m := propertyLineRegexp.FindStringSubmatch(l)
+		if m == nil {
+			return nil, content
+		}
+		if p := parsePropertyValue(m[2]); p != nil {
+			props[m[1]] = p
+		}
+	}
+	return props, strings.Join(lines[end+1:], "\n")
+}
+
+// parsePropertyValue parse a value of front-matter, nil if it is empty
+func parsePropertyValue(s string) *Property {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "~" || s == "null" {
+		return nil
+	}
+	switch s[0] {
+	case '"':
+		if q, err := strconv.QuotedPrefix(s); err == nil && isComment(s[len(q):]) {
+			v, _ := strconv.Unquote(q)
+			return &Property{Type: PropertyString, Value: v}
+		}
+	case '\'':
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			if isComment(s[i+1:]) {
+				v := strings.Replace(s[1:i], "''", "'", -1)
+				return &Property{Type: PropertyString, Value: v}
+			}
+			break
+		}
+	default:
+		if i := strings.Index(s, " #"); i != -1 {
+			s = strings.TrimSpace(s[:i])
+		}
+	}
+	return typedProperty(s)
+}
+
+// isComment tell whether s after a quoted value is blank or a comment
+func isComment(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || s[0] == '#'
+}
+
+// typedProperty guess type of an unquoted value
+func typedProperty(s string) *Property {
+	switch strings.ToLower(s) {
+	case "true":
+		return &Property{Type: PropertyBool, Value: true}
+	case "false":
+		return &Property{Type: PropertyBool, Value: false}
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil &&
+		!math.IsNaN(n) && !math.IsInf(n, 0) &&
+		!strings.ContainsAny(s, "xXpP_") {
+		return &Property{Type: PropertyNumber, Value: n, number: n}
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &Property{Type: PropertyDate, Value: s, date: t}
+		}
+	}
+	return &Property{Type: PropertyString, Value: s}
+}
+
+// ParsePropertyFilter parse a filter like "status=open", "due<2017-01-01",
+// "priority>=2", or "owner" for articles which have the property
+func ParsePropertyFilter(s string) (*PropertyFilter, error) {
+	for i := 0; i < len(s); i++ {
+		for _, op := range propertyOps {
+			if !strings.HasPrefix(s[i:], op) {
+				continue
+			}
+			name := strings.TrimSpace(s[:i])
+			if name == "" {
+				return nil, fmt.Errorf("no property name in filter %q", s)
+			}
+			value := parsePropertyValue(s[i+len(op):])
+			if value == nil {
+				value = &Property{Type: PropertyString, Value: ""}
+			}
+			return &PropertyFilter{Name: name, Op: op, Value: value}, nil
+		}
+	}
+	if name := strings.TrimSpace(s); name != "" {
+		return &PropertyFilter{Name: name}, nil
+	}
+	return nil, fmt.Errorf("empty property filter")
+}
+
+// match tell whether properties satisfy the filter
+func (f *PropertyFilter) match(props map[string]*Property) bool {
+	p, ok := props[f.Name]
+	if f.Op == "" {
+		return ok
+	}
+	if !ok {
+		return f.Op == "!="
+	}
+	c := compareProperties(p, f.Value)
+	switch f.Op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+// compareProperties compare values of the same type by the type, others
+// as strings, ignoring case
+func compareProperties(x, y *Property) int {
+	if x.Type == y.Type {
+		switch x.Type {
+		case PropertyNumber:
+			return compareFloats(x.number, y.number)
+		case PropertyDate:
+			switch {
+			case x.date.Before(y.date):
+				return -1
+			case x.date.After(y.date):
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(strings.ToLower(fmt.Sprint(x.Value)),
+		strings.ToLower(fmt.Sprint(y.Value)))
+}
+
+func compareFloats(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// QueryProperties find articles whose properties match all filters, among
+// descendants of under, or all articles if under is empty. They are
+// sorted by property order, prefix "-" to reverse it, articles without
+// the property last, or by title if order is empty. Articles hidden by
+// locked private articles are left out.
+func QueryProperties(session, under string, filters []*PropertyFilter, order string,
+	offset, limit int) (*PropertyResult, error) {
+	result := &PropertyResult{Hits: []*PropertyHit{}}
+	err := db.View(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+
+		var ids []string
+		if under == "" {
+			c.ForEach(func(k, _ []byte) error {
+				ids = append(ids, string(k))
+				return nil
+			})
+		} else {
+			if c.Bucket([]byte(under)) == nil {
+				return ErrArticleNotFound
+			}
+			ids = descendants(tx, under)
+		}
+
+		pv := newPrivacy(c, session)
+		for _, id := range ids {
+			b := c.Bucket([]byte(id))
+			if b == nil || pv.hidden(id) {
+				continue
+			}
+			props, _ := ParseProperties(string(b.Get(fContent)))
+			matched := true
+			for _, f := range filters {
+				if !f.match(props) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				result.Hits = append(result.Hits, &PropertyHit{
+					ArticleTitle: articleTitle([]byte(id), b),
+					Properties:   props,
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortPropertyHits(result.Hits, order)
+	result.Total = len(result.Hits)
+	if offset > len(result.Hits) {
+		offset = len(result.Hits)
+	}
+	result.Hits = result.Hits[offset:]
+	if limit > 0 && limit < len(result.Hits) {
+		result.Hits = result.Hits[:limit]
+	}
+	return result, nil
+}
+
+// descendants return ids of descendants of article id
+func descendants(tx Tx, id string) []string {
+	seen := map[string]bool{id: true}
+	var ids []string
+	queue := childrenOf(tx, id)
+	for i := 0; i < len(queue); i++ {
+		if seen[queue[i]] {
+			continue
+		}
+		seen[queue[i]] = true
+		ids = append(ids, queue[i])
+		queue = append(queue, childrenOf(tx, queue[i])...)
+	}
+	return ids
+}
+
+func sortPropertyHits(hits []*PropertyHit, order string) {
+	desc := strings.HasPrefix(order, "-")
+	name := strings.TrimPrefix(order, "-")
+	sort.SliceStable(hits, func(i, j int) bool {
+		if name != "" {
+			x, y := hits[i].Properties[name], hits[j].Properties[name]
+			switch {
+			case x != nil && y == nil:
+				return true
+			case x == nil && y != nil:
+				return false
+			case x != nil:
+				if c := compareProperties(x, y); c != 0 {
+					return (c < 0) != desc
+				}
+			}
+		}
+		return strings.ToLower(hits[i].Title) < strings.ToLower(hits[j].Title)
+	})
+}
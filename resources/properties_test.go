@@ -0,0 +1,106 @@
+package resources
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseProperties(t *testing.T) {
+	tests := []struct {
+		content string
+		props   map[string]interface{}
+		body    string
+	}{
+		{
+			content: "---\nstatus: open\npriority: 2\ndone: false\ndue: 2017-01-02\n---\nbody",
+			props: map[string]interface{}{
+				"status": "open", "priority": 2.0, "done": false, "due": "2017-01-02",
+			},
+			body: "body",
+		},
+		{
+			content: "---\n# comment\n\nowner: 'Bob''s' # who\nnote: \"a: b\"\n...\nbody",
+			props:   map[string]interface{}{"owner": "Bob's", "note": "a: b"},
+			body:    "body",
+		},
+		{
+			// not front-matter, content starts with a rule
+			content: "---\nintro\n---\nbody",
+			body:    "---\nintro\n---\nbody",
+		},
+		{
+			content: "---\nsome prose: with a colon\n---\nbody",
+			body:    "---\nsome prose: with a colon\n---\nbody",
+		},
+		{
+			content: "---\nstatus: open\n",
+			body:    "---\nstatus: open\n",
+		},
+		{
+			content: "status: open",
+			body:    "status: open",
+		},
+	}
+	for _, tt := range tests {
+		props, body := ParseProperties(tt.content)
+		if body != tt.body {
+			t.Errorf("ParseProperties(%q) body = %q, want %q", tt.content, body, tt.body)
+		}
+		if len(props) != len(tt.props) {
+			t.Errorf("ParseProperties(%q) = %d properties, want %d", tt.content,
+				len(props), len(tt.props))
+			continue
+		}
+		for name, want := range tt.props {
+			if p := props[name]; p == nil || p.Value != want {
+				t.Errorf("ParseProperties(%q)[%q] = %v, want %v", tt.content, name,
+					p, want)
+			}
+		}
+	}
+}
+
+func TestTypedPropertyNonFinite(t *testing.T) {
+	for _, s := range []string{"NaN", "nan", "Inf", "+Inf", "-infinity", "1e999"} {
+		p := typedProperty(s)
+		if p.Type != PropertyString || p.Value != s {
+			t.Errorf("typedProperty(%q) = %s %v, want string", s, p.Type, p.Value)
+		}
+		if _, err := json.Marshal(p); err != nil {
+			t.Errorf("json.Marshal(typedProperty(%q)): %v", s, err)
+		}
+	}
+	if p := typedProperty("1.5e3"); p.Type != PropertyNumber || p.Value != 1500.0 {
+		t.Errorf("typedProperty(\"1.5e3\") = %s %v, want number", p.Type, p.Value)
+	}
+}
+
+func TestPropertyFilter(t *testing.T) {
+	props, _ := ParseProperties("---\npriority: 2\ndue: 2017-01-02\nstatus: Open\n---\n")
+	tests := []struct {
+		filter string
+		match  bool
+	}{
+		{"priority>=2", true},
+		{"priority<2", false},
+		{"priority=10", false},
+		{"due<2017-02-01", true},
+		{"status=open", true},
+		{"status!=open", false},
+		{"owner!=bob", true},
+		{"owner", false},
+		{"due", true},
+	}
+	for _, tt := range tests {
+		f, err := ParsePropertyFilter(tt.filter)
+		if err != nil {
+			t.Fatalf("ParsePropertyFilter(%q): %v", tt.filter, err)
+		}
+		if m := f.match(props); m != tt.match {
+			t.Errorf("filter %q matched %v, want %v", tt.filter, m, tt.match)
+		}
+	}
+	if _, err := ParsePropertyFilter("=1"); err == nil {
+		t.Error("ParsePropertyFilter(\"=1\") gave no error")
+	}
+}
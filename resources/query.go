@@ -0,0 +1,347 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// QueryError a syntax error in a search query
+type QueryError struct {
+	Message string `json:"error"`
+	// Position offset of the error in query, in characters
+	Position int `json:"position"`
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+}
+
+// search query fields
+const (
+	queryFieldTitle   = "title"
+	queryFieldContent = "content"
+	queryFieldDiagram = "diagram"
+	queryFieldUnder   = "under"
+	queryFieldTag     = "tag"
+)
+
+// weights of fields which a term is searched in
+var queryFieldWeights = map[string][fieldCount]float64{
+	"":                {3, 1, 0},
+	queryFieldTitle:   {1, 0, 0},
+	queryFieldContent: {0, 1, 0},
+	queryFieldDiagram: {0, 0, 1},
+}
+
+type lexemeKind int
+
+const (
+	lexWord lexemeKind = iota
+	lexPhrase
+	lexLeftParen
+	lexRightParen
+	lexAnd
+	lexOr
+	lexNot
+)
+
+type lexeme struct {
+	kind  lexemeKind
+	field string
+	text  string
+	pos   int
+	// prefix the word is being typed, matches terms it prefixes
+	prefix bool
+}
+
+// query syntax tree nodes
+type (
+	queryNode interface{}
+
+	// termNode a word or a phrase, searched in field
+	termNode struct {
+		field  string
+		terms  []string
+		prefix bool
+		pos    int
+	}
+	// underNode descendants of an article
+	underNode struct {
+		id  string
+		pos int
+	}
+	// tagNode articles tagged with tag
+	tagNode struct{ tag string }
+	andNode struct{ children []queryNode }
+	orNode  struct{ children []queryNode }
+	notNode struct{ child queryNode }
+)
+
+// parseQuery parse a search query. A query is made of words and "quoted
+// phrases", combined by AND (or just a space), OR, NOT (or a leading -) and
+// (parentheses). Prefixes title:, content: and diagram: search one field,
+// under:<id> limits results to descendants of an article, tag:<tag> to
+// articles tagged with it.
+// A nil node is returned for a query without any term.
+func parseQuery(query string) (queryNode, error) {
+	lexemes, err := lexQuery(query)
+	if err != nil || len(lexemes) == 0 {
+		return nil, err
+	}
+	p := &queryParser{lexemes: lexemes, end: utf8.RuneCountInString(query)}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if l := p.peek(); l != nil {
+		return nil, &QueryError{Message: "unexpected )", Position: l.pos}
+	}
+	return node, nil
+}
+
+func lexQuery(query string) ([]*lexeme, error) {
+	var lexemes []*lexeme
+	pos := func(i int) int { return utf8.RuneCountInString(query[:i]) }
+
+	for i := 0; i < len(query); {
+		r, size := utf8.DecodeRuneInString(query[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			lexemes = append(lexemes, &lexeme{kind: lexLeftParen, pos: pos(i)})
+			i++
+		case r == ')':
+			lexemes = append(lexemes, &lexeme{kind: lexRightParen, pos: pos(i)})
+			i++
+		case r == '"':
+			text, next, err := readPhrase(query, i)
+			if err != nil {
+				return nil, err
+			}
+			lexemes = append(lexemes,
+				&lexeme{kind: lexPhrase, text: text, pos: pos(i)})
+			i = next
+		case r == '-' && i+1 < len(query) && !isQueryDelimiter(query[i+1]):
+			lexemes = append(lexemes, &lexeme{kind: lexNot, pos: pos(i)})
+			i++
+		default:
+			start := i
+			for i < len(query) && !isQueryDelimiter(query[i]) {
+				i++
+			}
+			word := query[start:i]
+
+			switch word {
+			case "AND":
+				lexemes = append(lexemes, &lexeme{kind: lexAnd, pos: pos(start)})
+				continue
+			case "OR":
+				lexemes = append(lexemes, &lexeme{kind: lexOr, pos: pos(start)})
+				continue
+			case "NOT":
+				lexemes = append(lexemes, &lexeme{kind: lexNot, pos: pos(start)})
+				continue
+			}
+
+			l := &lexeme{kind: lexWord, text: word, pos: pos(start)}
+			if c := strings.Index(word, ":"); c > 0 && isQueryField(word[:c]) {
+				l.field = strings.ToLower(word[:c])
+				l.text = word[c+1:]
+				if l.text == "" && i < len(query) && query[i] == '"' {
+					text, next, err := readPhrase(query, i)
+					if err != nil {
+						return nil, err
+					}
+					l.kind, l.text = lexPhrase, text
+					i = next
+				}
+				if l.text == "" {
+					return nil, &QueryError{
+						Message:  "missing value after " + l.field + ":",
+						Position: pos(start),
+					}
+				}
+			}
+			lexemes = append(lexemes, l)
+		}
+	}
+
+	// the last word is being typed if query does not end with a delimiter
+	if n := len(lexemes); n > 0 && lexemes[n-1].kind == lexWord {
+		r, _ := utf8.DecodeLastRuneInString(query)
+		lexemes[n-1].prefix = unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	return lexemes, nil
+}
+
+// readPhrase read a quoted phrase starting at i, returns its text and
+// the index after closing quote
+func readPhrase(query string, i int) (string, int, error) {
+	end := strings.IndexByte(query[i+1:], '"')
+	if end == -1 {
+		return "", 0, &QueryError{
+			Message:  "missing closing quote",
+			Position: utf8.RuneCountInString(query[:i]),
+		}
+	}
+	return query[i+1 : i+1+end], i + 1 + end + 1, nil
+}
+
+func isQueryDelimiter(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' ||
+		c == '(' || c == ')' || c == '"'
+}
+
+func isQueryField(name string) bool {
+	switch strings.ToLower(name) {
+	case queryFieldTitle, queryFieldContent, queryFieldDiagram, queryFieldUnder,
+		queryFieldTag:
+		return true
+	}
+	return false
+}
+
+type queryParser struct {
+	lexemes []*lexeme
+	next    int
+	// end position of query, for errors at the end
+	end int
+}
+
+func (p *queryParser) peek() *lexeme {
+	if p.next < len(p.lexemes) {
+		return p.lexemes[p.next]
+	}
+	return nil
+}
+
+func (p *queryParser) pos() int {
+	if l := p.peek(); l != nil {
+		return l.pos
+	}
+	return p.end
+}
+
+// parseOr or := and ("OR" and)*
+func (p *queryParser) parseOr() (queryNode, error) {
+	var children []queryNode
+	for {
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+
+		if l := p.peek(); l == nil || l.kind != lexOr {
+			break
+		}
+		p.next++
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &orNode{children: children}, nil
+}
+
+// parseAnd and := not (["AND"] not)*
+func (p *queryParser) parseAnd() (queryNode, error) {
+	var children []queryNode
+	for {
+		l := p.peek()
+		if l == nil || l.kind == lexOr || l.kind == lexRightParen {
+			break
+		}
+		if l.kind == lexAnd {
+			if len(children) == 0 {
+				return nil, &QueryError{Message: "missing term before AND",
+					Position: l.pos}
+			}
+			p.next++
+			if n := p.peek(); n == nil || n.kind == lexOr ||
+				n.kind == lexRightParen || n.kind == lexAnd {
+				return nil, &QueryError{Message: "missing term after AND",
+					Position: p.pos()}
+			}
+			continue
+		}
+
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+
+	if len(children) == 0 {
+		msg := "missing search term"
+		if l := p.peek(); l != nil && l.kind == lexOr {
+			msg = "missing term before OR"
+		}
+		return nil, &QueryError{Message: msg, Position: p.pos()}
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &andNode{children: children}, nil
+}
+
+// parseNot not := ("NOT" | "-") not | primary
+func (p *queryParser) parseNot() (queryNode, error) {
+	l := p.peek()
+	if l.kind != lexNot {
+		return p.parsePrimary()
+	}
+	p.next++
+	if n := p.peek(); n == nil || n.kind == lexOr || n.kind == lexAnd ||
+		n.kind == lexRightParen {
+		return nil, &QueryError{Message: "missing term after NOT",
+			Position: p.pos()}
+	}
+	child, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return &notNode{child: child}, nil
+}
+
+// parsePrimary primary := "(" or ")" | [field:] word | [field:] "phrase"
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	l := p.peek()
+	p.next++
+
+	switch l.kind {
+	case lexLeftParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if r := p.peek(); r == nil || r.kind != lexRightParen {
+			return nil, &QueryError{Message: "missing )", Position: p.pos()}
+		}
+		p.next++
+		return node, nil
+	case lexWord, lexPhrase:
+		switch l.field {
+		case queryFieldUnder:
+			return &underNode{id: l.text, pos: l.pos}, nil
+		case queryFieldTag:
+			return &tagNode{tag: normalizeTag(l.text)}, nil
+		}
+		n := &termNode{
+			field:  l.field,
+			terms:  terms(l.text),
+			prefix: l.prefix,
+			pos:    l.pos,
+		}
+		// a single CJK character is only indexed as part of bigrams
+		if len(n.terms) == 1 && utf8.RuneCountInString(n.terms[0]) == 1 {
+			r, _ := utf8.DecodeRuneInString(n.terms[0])
+			n.prefix = n.prefix || isCJK(r)
+		}
+		return n, nil
+	}
+	return nil, &QueryError{Message: "unexpected token", Position: l.pos}
+}
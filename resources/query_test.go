@@ -0,0 +1,103 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// formatQuery write a query syntax tree as an expression
+func formatQuery(node queryNode) string {
+	join := func(op string, children []queryNode) string {
+		s := make([]string, len(children))
+		for i, c := range children {
+			s[i] = formatQuery(c)
+		}
+		return "(" + strings.Join(s, " "+op+" ") + ")"
+	}
+	switch n := node.(type) {
+	case nil:
+		return "nil"
+	case *termNode:
+		s := strings.Join(n.terms, "_")
+		if n.field != "" {
+			s = n.field + ":" + s
+		}
+		if n.prefix {
+			s += "*"
+		}
+		return s
+	case *underNode:
+		return "under:" + n.id
+	case *tagNode:
+		return "tag:" + n.tag
+	case *notNode:
+		return "-" + formatQuery(n.child)
+	case *andNode:
+		return join("AND", n.children)
+	case *orNode:
+		return join("OR", n.children)
+	}
+	return fmt.Sprintf("%T", node)
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "nil"},
+		{"  ", "nil"},
+		{"apple", "apple*"},
+		{"apple ", "apple"},
+		{"Apple pie", "(apple AND pie*)"},
+		{"apple AND pie ", "(apple AND pie)"},
+		{"a OR b c ", "(a OR (b AND c))"},
+		{"(a OR b) c ", "((a OR b) AND c)"},
+		{"-a NOT b ", "(-a AND -b)"},
+		{"NOT -a ", "--a"},
+		{`"big apple" `, "big_apple"},
+		{`title:"big apple" content:pie `, "(title:big_apple AND content:pie)"},
+		{"Title:pie ", "title:pie"},
+		{"tag:Work under:abc ", "(tag:Work AND under:abc)"},
+		{"a-b ", "a_b"},
+		{"中", "中*"},
+		// a word without any term
+		{"!! ", ""},
+	}
+	for _, tt := range tests {
+		node, err := parseQuery(tt.query)
+		if err != nil {
+			t.Errorf("parseQuery(%q): %v", tt.query, err)
+			continue
+		}
+		if got := formatQuery(node); got != tt.want {
+			t.Errorf("parseQuery(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query    string
+		message  string
+		position int
+	}{
+		{`"apple`, "missing closing quote", 0},
+		{"(apple", "missing )", 6},
+		{"apple)", "unexpected )", 5},
+		{"AND apple", "missing term before AND", 0},
+		{"apple AND", "missing term after AND", 9},
+		{"OR apple", "missing term before OR", 0},
+		{"apple NOT", "missing term after NOT", 9},
+		{"été title:", "missing value after title:", 4},
+	}
+	for _, tt := range tests {
+		_, err := parseQuery(tt.query)
+		e, ok := err.(*QueryError)
+		if !ok || e.Message != tt.message || e.Position != tt.position {
+			t.Errorf("parseQuery(%q) error = %v, want %q at %d", tt.query, err,
+				tt.message, tt.position)
+		}
+	}
+}
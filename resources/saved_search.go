@@ -0,0 +1,218 @@
+package resources
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// savedSearchesCollectionName names the collection of saved searches
+var savedSearchesCollectionName = []byte("SavedSearches")
+
+// saved search field names
+var fQuery = []byte("Query")
+
+// SavedSearchNodePrefix prefixes ids of saved searches when they are shown
+// as virtual articles in navigation
+const SavedSearchNodePrefix = "search:"
+
+// ErrSavedSearchNotFound returned when a saved search does not exist
+var ErrSavedSearchNotFound = errors.New("saved search not found")
+
+// SavedSearch a named query, shown as a virtual article under Parent
+// whose sub-articles are the articles matching the query
+type SavedSearch struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	Parent    string    `json:"parent"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Version counts changes, as of articles
+	Version uint64 `json:"version"`
+}
+
+// NodeID returns id of saved search as a virtual article
+func (s *SavedSearch) NodeID() string {
+	return SavedSearchNodePrefix + s.ID
+}
+
+// SavedSearchID returns id of saved search from id of a virtual article,
+// ok is false if nodeID is not one
+func SavedSearchID(nodeID string) (id string, ok bool) {
+	if !strings.HasPrefix(nodeID, SavedSearchNodePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(nodeID, SavedSearchNodePrefix), true
+}
+
+// GetSavedSearch get a saved search by its id
+func GetSavedSearch(id string) (*SavedSearch, error) {
+	var s *SavedSearch
+	err := db.View(func(tx Tx) error {
+		x := tx.Bucket(savedSearchesCollectionName)
+		if x == nil || x.Bucket([]byte(id)) == nil {
+			return ErrSavedSearchNotFound
+		}
+		s = savedSearch(tx, []byte(id), x.Bucket([]byte(id)))
+		return nil
+	})
+	return s, err
+}
+
+// ListSavedSearches list all saved searches by name
+func ListSavedSearches() ([]*SavedSearch, error) {
+	searches := []*SavedSearch{}
+	err := db.View(func(tx Tx) error {
+		x := tx.Bucket(savedSearchesCollectionName)
+		if x == nil {
+			return nil
+		}
+		return x.ForEach(func(k, _ []byte) error {
+			searches = append(searches, savedSearch(tx, k, x.Bucket(k)))
+			return nil
+		})
+	})
+	sortSavedSearches(searches)
+	return searches, err
+}
+
+// SavedSearchesUnder return saved searches shown under an article,
+// as virtual articles. None are shown under a locked private article.
+func SavedSearchesUnder(session, parent string) ([]*ArticleTitle, error) {
+	if err := CheckUnlocked(session, parent); err != nil {
+		if _, ok := err.(*PrivateError); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	searches, err := ListSavedSearches()
+	if err != nil {
+		return nil, err
+	}
+	var titles []*ArticleTitle
+	for _, s := range searches {
+		if s.Parent == parent {
+			titles = append(titles, &ArticleTitle{
+				ID:        s.NodeID(),
+				Title:     s.Name,
+				CreatedAt: s.CreatedAt,
+				UpdatedAt: s.CreatedAt,
+			})
+		}
+	}
+	return titles, nil
+}
+
+// Create store saved search, its id is generated.
+// Parent defaults to root article.
+func (s *SavedSearch) Create() error {
+	var err error
+	s.ID, err = newID()
+	if err != nil {
+		return err
+	}
+	s.CreatedAt = time.Now()
+	return s.put(true)
+}
+
+// Update change name, query and parent of saved search
+func (s *SavedSearch) Update() error {
+	return s.put(false)
+}
+
+func (s *SavedSearch) put(create bool) error {
+	if s.Name == "" {
+		return errors.New("empty name")
+	}
+	if _, err := parseQuery(s.Query); err != nil {
+		return err
+	}
+	if s.Parent == "" {
+		s.Parent = RootArticleID
+	}
+
+	return db.Update(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		if c.Bucket([]byte(s.Parent)) == nil {
+			return ErrArticleNotFound
+		}
+
+		x, err := tx.CreateBucketIfNotExists(savedSearchesCollectionName)
+		if err != nil {
+			return err
+		}
+		b := x.Bucket([]byte(s.ID))
+		if create {
+			if b, err = x.CreateBucket([]byte(s.ID)); err != nil {
+				return err
+			}
+			if err = b.Put(fCreatedAt, formatTime(s.CreatedAt)); err != nil {
+				return err
+			}
+		} else if b == nil {
+			return ErrSavedSearchNotFound
+		} else {
+			s.CreatedAt = parseTime(b.Get(fCreatedAt))
+		}
+
+		if err = b.Put(fName, []byte(s.Name)); err != nil {
+			return err
+		}
+		if err = b.Put(fQuery, []byte(s.Query)); err != nil {
+			return err
+		}
+		if err = b.Put(fParent, []byte(s.Parent)); err != nil {
+			return err
+		}
+		s.Version, err = bumpVersion(b)
+		return err
+	})
+}
+
+// DeleteSavedSearch delete a saved search
+func DeleteSavedSearch(id string) error {
+	return db.Update(func(tx Tx) error {
+		x := tx.Bucket(savedSearchesCollectionName)
+		if x == nil || x.Bucket([]byte(id)) == nil {
+			return ErrSavedSearchNotFound
+		}
+		return x.DeleteBucket([]byte(id))
+	})
+}
+
+// Run search all articles matching saved search, ranked by relevance
+func (s *SavedSearch) Run(session string) (*SearchResult, error) {
+	return SearchArticles(session, s.Query, SortByPosition, 0, 0)
+}
+
+// savedSearch read saved search stored in b. It is shown under root
+// article if its parent no longer exists.
+func savedSearch(tx Tx, id []byte, b Bucket) *SavedSearch {
+	s := &SavedSearch{
+		ID:        string(id),
+		Name:      string(b.Get(fName)),
+		Query:     string(b.Get(fQuery)),
+		Parent:    string(b.Get(fParent)),
+		CreatedAt: parseTime(b.Get(fCreatedAt)),
+		Version:   decodeVersion(b.Get(fVersion)),
+	}
+	if c, err := articleCollection(tx); err != nil ||
+		c.Bucket([]byte(s.Parent)) == nil {
+		s.Parent = RootArticleID
+	}
+	return s
+}
+
+func sortSavedSearches(searches []*SavedSearch) {
+	sort.Slice(searches, func(i, j int) bool {
+		x, y := strings.ToLower(searches[i].Name), strings.ToLower(searches[j].Name)
+		if x != y {
+			return x < y
+		}
+		return searches[i].ID < searches[j].ID
+	})
+}
@@ -0,0 +1,662 @@
+package resources
+
+import (
+	"encoding/binary"
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// from term to article ids, each maps to term frequencies of fields
+	searchIndexCollectionName = []byte("SearchIndex")
+	// from article id to numbers of terms of fields
+	searchDocsCollectionName = []byte("SearchDocs")
+	// numbers of indexed articles and of their terms of fields, by
+	// searchStatsKey
+	searchStatsCollectionName = []byte("SearchStats")
+	searchStatsKey            = []byte("total")
+)
+
+// searchable fields, in order of encoding
+const (
+	fieldTitle = iota
+	fieldContent
+	fieldDiagram
+	fieldCount
+)
+
+var searchFields = [fieldCount][]byte{fTitle, fContent, fDiagram}
+
+// weights of fields in ranking, diagram is indexed but not ranked
+var fieldWeights = [fieldCount]float64{3, 1, 0}
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+const (
+	// maxPrefixExpansions limits terms matched by the prefix of the last
+	// query term
+	maxPrefixExpansions = 64
+
+	snippetLength = 200
+	snippetBefore = 60
+)
+
+// SearchHit an article matched by a search
+type SearchHit struct {
+	*ArticleTitle
+	Score     float64 `json:"score"`
+	TitleHTML string  `json:"titleHTML"`
+	Snippet   string  `json:"snippet"`
+}
+
+// SearchResult a page of search hits
+type SearchResult struct {
+	Total int          `json:"total"`
+	Hits  []*SearchHit `json:"hits"`
+}
+
+// postings term frequencies of fields by article id
+type postings map[string]*[fieldCount]int
+
+// docSet scores of articles by id, nil means all articles
+type docSet map[string]float64
+
+// SearchArticles search articles by query, see parseQuery for its syntax.
+// Hits are ranked by relevance, or sorted in order if it is not empty.
+// Articles hidden by locked private articles are left out.
+func SearchArticles(session, query, order string, offset, limit int) (
+	*SearchResult, error) {
+	result := &SearchResult{}
+	node, err := parseQuery(query)
+	if err != nil || node == nil {
+		return result, err
+	}
+
+	var less func(x, y *ArticleTitle) bool
+	if order != SortByPosition {
+		if less, err = articleTitleLess(order); err != nil {
+			return nil, err
+		}
+	}
+
+	err = db.View(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		e, err := newQueryEvaluator(tx)
+		if err != nil || e == nil {
+			return err
+		}
+		scores, err := e.eval(node, false)
+		if err != nil {
+			return err
+		}
+		if scores == nil {
+			// query has no term
+			return nil
+		}
+
+		pv := newPrivacy(c, session)
+		for id, score := range scores {
+			b := c.Bucket([]byte(id))
+			if b == nil || pv.hidden(id) {
+				continue
+			}
+			result.Hits = append(result.Hits,
+				&SearchHit{ArticleTitle: articleTitle([]byte(id), b), Score: score})
+		}
+		sortHits(result.Hits, less)
+		result.Total = len(result.Hits)
+		result.Hits = page(result.Hits, offset, limit)
+
+		for _, h := range result.Hits {
+			b := c.Bucket([]byte(h.ID))
+			h.TitleHTML = highlight(string(b.Get(fTitle)), e.matched, 0, -1)
+			h.Snippet = snippet(string(b.Get(fContent)), e.matched)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// queryEvaluator evaluate query syntax trees against search index
+type queryEvaluator struct {
+	tx       Tx
+	idx      Bucket
+	docs     Bucket
+	articles Bucket
+
+	// collection statistics
+	n         int
+	avgLength [fieldCount]float64
+
+	// matched terms, for highlighting
+	matched map[string]bool
+}
+
+// newQueryEvaluator returns nil if search index is absent
+func newQueryEvaluator(tx Tx) (*queryEvaluator, error) {
+	c, err := articleCollection(tx)
+	if err != nil {
+		return nil, err
+	}
+	e := &queryEvaluator{
+		tx:       tx,
+		idx:      tx.Bucket(searchIndexCollectionName),
+		docs:     tx.Bucket(searchDocsCollectionName),
+		articles: c,
+		matched:  make(map[string]bool),
+	}
+	if e.idx == nil || e.docs == nil {
+		return nil, nil
+	}
+
+	n, lengths := searchStats(tx)
+	e.n = n
+	for f, l := range lengths {
+		if n > 0 {
+			e.avgLength[f] = float64(l) / float64(n)
+		}
+	}
+	return e, nil
+}
+
+func (e *queryEvaluator) eval(node queryNode, negated bool) (docSet, error) {
+	switch n := node.(type) {
+	case *termNode:
+		return e.evalTerm(n, negated), nil
+	case *underNode:
+		return e.evalUnder(n)
+	case *tagNode:
+		return e.evalTag(n), nil
+	case *notNode:
+		set, err := e.eval(n.child, !negated)
+		if err != nil || set == nil {
+			// nothing to exclude
+			return nil, err
+		}
+		all := e.all()
+		for id := range set {
+			delete(all, id)
+		}
+		return all, nil
+	case *andNode:
+		var result docSet
+		for _, child := range n.children {
+			set, err := e.eval(child, negated)
+			if err != nil {
+				return nil, err
+			}
+			if set == nil {
+				continue
+			}
+			if result == nil {
+				result = set
+				continue
+			}
+			for id, score := range result {
+				if s, ok := set[id]; ok {
+					result[id] = score + s
+				} else {
+					delete(result, id)
+				}
+			}
+		}
+		return result, nil
+	case *orNode:
+		// operands without constraint are left out
+		var result docSet
+		for _, child := range n.children {
+			set, err := e.eval(child, negated)
+			if err != nil {
+				return nil, err
+			}
+			if set == nil {
+				continue
+			}
+			if result == nil {
+				result = docSet{}
+			}
+			for id, score := range set {
+				result[id] += score
+			}
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("unknown query node %T", node)
+}
+
+// evalTerm score articles matching a word or phrase by BM25F,
+// returns nil if it has no term
+func (e *queryEvaluator) evalTerm(n *termNode, negated bool) docSet {
+	if len(n.terms) == 0 {
+		return nil
+	}
+	weights := queryFieldWeights[n.field]
+
+	var avgLength float64
+	for f, l := range e.avgLength {
+		avgLength += l * weights[f]
+	}
+	if avgLength == 0 {
+		avgLength = 1
+	}
+
+	var lists []postings
+	for i, t := range n.terms {
+		alternatives := []string{t}
+		if n.prefix && i == len(n.terms)-1 {
+			alternatives = expandPrefix(e.idx, t)
+		}
+		p := make(postings)
+		for _, alt := range alternatives {
+			if collectPostings(e.idx, alt, p) && !negated {
+				e.matched[alt] = true
+			}
+		}
+		lists = append(lists, p)
+	}
+
+	set := docSet{}
+	for id := range lists[0] {
+		var score float64
+		length := weightedLength(decodeCounts(e.docs.Get([]byte(id))), weights)
+		for _, p := range lists {
+			tfs, ok := p[id]
+			if !ok {
+				score = 0
+				break
+			}
+			tf := weightedLength(*tfs, weights)
+			if tf == 0 {
+				score = 0
+				break
+			}
+			df := float64(len(p))
+			idf := math.Log(1 + (float64(e.n)-df+0.5)/(df+0.5))
+			score += idf * tf * (bm25K1 + 1) /
+				(tf + bm25K1*(1-bm25B+bm25B*length/avgLength))
+		}
+		if score > 0 && (len(n.terms) == 1 || e.hasPhrase(id, n, weights)) {
+			set[id] = score
+		}
+	}
+	return set
+}
+
+// hasPhrase check whether terms of n appear in sequence in a field of
+// article id which has weight
+func (e *queryEvaluator) hasPhrase(id string, n *termNode,
+	weights [fieldCount]float64) bool {
+	b := e.articles.Bucket([]byte(id))
+	if b == nil {
+		return false
+	}
+	last := len(n.terms) - 1
+	for f, name := range searchFields {
+		if weights[f] == 0 {
+			continue
+		}
+		ts := terms(string(b.Get(name)))
+		for i := 0; i+last < len(ts); i++ {
+			j := 0
+			for ; j < last && ts[i+j] == n.terms[j]; j++ {
+			}
+			if j < last {
+				continue
+			}
+			if t := ts[i+last]; t == n.terms[last] ||
+				(n.prefix && strings.HasPrefix(t, n.terms[last])) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evalUnder return descendants of an article
+func (e *queryEvaluator) evalUnder(n *underNode) (docSet, error) {
+	if e.articles.Bucket([]byte(n.id)) == nil {
+		return nil, &QueryError{Message: "article not found", Position: n.pos}
+	}
+	set := docSet{}
+	ids := childrenOf(e.tx, n.id)
+	for i := 0; i < len(ids); i++ {
+		if _, ok := set[ids[i]]; ok {
+			continue
+		}
+		set[ids[i]] = 0
+		ids = append(ids, childrenOf(e.tx, ids[i])...)
+	}
+	return set, nil
+}
+
+// evalTag return articles tagged with a tag
+func (e *queryEvaluator) evalTag(n *tagNode) docSet {
+	set := docSet{}
+	if t := e.tx.Bucket(tagsCollectionName); t != nil {
+		if x := t.Bucket([]byte(n.tag)); x != nil {
+			x.ForEach(func(k, _ []byte) error {
+				set[string(k)] = 0
+				return nil
+			})
+		}
+	}
+	return set
+}
+
+// all return all indexed articles
+func (e *queryEvaluator) all() docSet {
+	set := docSet{}
+	e.docs.ForEach(func(k, _ []byte) error {
+		set[string(k)] = 0
+		return nil
+	})
+	return set
+}
+
+func weightedLength(counts [fieldCount]int, weights [fieldCount]float64) float64 {
+	var l float64
+	for f, c := range counts {
+		l += float64(c) * weights[f]
+	}
+	return l
+}
+
+func sortHits(hits []*SearchHit, less func(x, y *ArticleTitle) bool) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		if less != nil {
+			return less(hits[i].ArticleTitle, hits[j].ArticleTitle)
+		}
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+}
+
+func page(hits []*SearchHit, offset, limit int) []*SearchHit {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(hits) {
+		offset = len(hits)
+	}
+	hits = hits[offset:]
+	if limit > 0 && limit < len(hits) {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// expandPrefix return indexed terms which start with prefix
+func expandPrefix(idx Bucket, prefix string) []string {
+	var out []string
+	cursor := idx.Cursor()
+	p := []byte(prefix)
+	for k, _ := cursor.Seek(p); k != nil && strings.HasPrefix(string(k), prefix); k, _ = cursor.Next() {
+		out = append(out, string(k))
+		if len(out) == maxPrefixExpansions {
+			break
+		}
+	}
+	return out
+}
+
+// collectPostings add postings of term into p, returns whether term exists
+func collectPostings(idx Bucket, term string, p postings) bool {
+	x := idx.Bucket([]byte(term))
+	if x == nil {
+		return false
+	}
+	x.ForEach(func(k, v []byte) error {
+		tfs, ok := p[string(k)]
+		if !ok {
+			tfs = &[fieldCount]int{}
+			p[string(k)] = tfs
+		}
+		for f, c := range decodeCounts(v) {
+			tfs[f] += c
+		}
+		return nil
+	})
+	return true
+}
+
+// snippet extract a piece of text around the first matched term,
+// with matched terms highlighted
+func snippet(text string, matched map[string]bool) string {
+	start := 0
+	for _, t := range tokenize(text) {
+		if matched[t.term] {
+			start = t.start - snippetBefore
+			break
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+	// align to a word boundary
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	if i := strings.IndexAny(text[start:], " \t\n"); start > 0 && i != -1 &&
+		i < snippetBefore/2 {
+		start += i + 1
+	}
+
+	end := start + snippetLength
+	if end >= len(text) {
+		end = len(text)
+	} else {
+		for end > start && !utf8.RuneStart(text[end]) {
+			end--
+		}
+	}
+
+	out := highlight(text, matched, start, end)
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(text) {
+		out += "…"
+	}
+	return out
+}
+
+// highlight escape text[start:end] as HTML, with matched terms wrapped
+// in <mark>, end -1 means the end of text
+func highlight(text string, matched map[string]bool, start, end int) string {
+	if end == -1 {
+		end = len(text)
+	}
+
+	// ranges to mark, overlapping ones (of CJK bigrams) are merged
+	var ranges [][2]int
+	for _, t := range tokenize(text[start:end]) {
+		if !matched[t.term] {
+			continue
+		}
+		if n := len(ranges); n > 0 && t.start <= ranges[n-1][1] {
+			if t.end > ranges[n-1][1] {
+				ranges[n-1][1] = t.end
+			}
+			continue
+		}
+		ranges = append(ranges, [2]int{t.start, t.end})
+	}
+
+	var out strings.Builder
+	last := start
+	for _, r := range ranges {
+		out.WriteString(html.EscapeString(text[last : start+r[0]]))
+		out.WriteString("<mark>")
+		out.WriteString(html.EscapeString(text[start+r[0] : start+r[1]]))
+		out.WriteString("</mark>")
+		last = start + r[1]
+	}
+	out.WriteString(html.EscapeString(text[last:end]))
+	return out.String()
+}
+
+// indexText add searchable fields of article stored in b to search index
+func indexText(tx Tx, id []byte, b Bucket) error {
+	idx, err := tx.CreateBucketIfNotExists(searchIndexCollectionName)
+	if err != nil {
+		return err
+	}
+	docs, err := tx.CreateBucketIfNotExists(searchDocsCollectionName)
+	if err != nil {
+		return err
+	}
+
+	tfs, lengths := fieldTerms(b)
+	for term, counts := range tfs {
+		x, err := idx.CreateBucketIfNotExists([]byte(term))
+		if err != nil {
+			return err
+		}
+		if err = x.Put(id, encodeCounts(*counts)); err != nil {
+			return err
+		}
+	}
+	if v := docs.Get(id); v != nil {
+		if err := addSearchStats(tx, -1, decodeCounts(v)); err != nil {
+			return err
+		}
+	}
+	if err := docs.Put(id, encodeCounts(lengths)); err != nil {
+		return err
+	}
+	return addSearchStats(tx, 1, lengths)
+}
+
+// unindexText remove article stored in b from search index
+func unindexText(tx Tx, id []byte, b Bucket) error {
+	tfs, _ := fieldTerms(b)
+	for term := range tfs {
+		err := removeFromIndex(tx, searchIndexCollectionName, term, id)
+		if err != nil {
+			return err
+		}
+	}
+	docs := tx.Bucket(searchDocsCollectionName)
+	if docs == nil || docs.Get(id) == nil {
+		return nil
+	}
+	if err := addSearchStats(tx, -1, decodeCounts(docs.Get(id))); err != nil {
+		return err
+	}
+	return docs.Delete(id)
+}
+
+// searchStats return numbers of indexed articles and of their terms of
+// fields, which are counted from the index if they are not kept
+func searchStats(tx Tx) (n int, lengths [fieldCount]int) {
+	if st := tx.Bucket(searchStatsCollectionName); st != nil {
+		return decodeSearchStats(st.Get(searchStatsKey))
+	}
+	if docs := tx.Bucket(searchDocsCollectionName); docs != nil {
+		docs.ForEach(func(_, v []byte) error {
+			n++
+			for f, l := range decodeCounts(v) {
+				lengths[f] += l
+			}
+			return nil
+		})
+	}
+	return
+}
+
+// addSearchStats add sign times an article with lengths of fields to
+// search statistics
+func addSearchStats(tx Tx, sign int, lengths [fieldCount]int) error {
+	n, total := searchStats(tx)
+	n += sign
+	for f, l := range lengths {
+		total[f] += sign * l
+	}
+	st, err := tx.CreateBucketIfNotExists(searchStatsCollectionName)
+	if err != nil {
+		return err
+	}
+	return st.Put(searchStatsKey, encodeSearchStats(n, total))
+}
+
+// keepSearchStats count search statistics of a database indexed before
+// they were kept, see searchStats
+func keepSearchStats(tx Tx) error {
+	if tx.Bucket(searchStatsCollectionName) != nil {
+		return nil
+	}
+	n, lengths := searchStats(tx)
+	st, err := tx.CreateBucket(searchStatsCollectionName)
+	if err != nil {
+		return err
+	}
+	return st.Put(searchStatsKey, encodeSearchStats(n, lengths))
+}
+
+func encodeSearchStats(n int, lengths [fieldCount]int) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	v := append([]byte{}, buf[:binary.PutUvarint(buf, uint64(n))]...)
+	return append(v, encodeCounts(lengths)...)
+}
+
+func decodeSearchStats(v []byte) (n int, lengths [fieldCount]int) {
+	c, size := binary.Uvarint(v)
+	if size <= 0 {
+		return 0, lengths
+	}
+	return int(c), decodeCounts(v[size:])
+}
+
+// fieldTerms count terms of searchable fields of article stored in b
+func fieldTerms(b Bucket) (tfs map[string]*[fieldCount]int,
+	lengths [fieldCount]int) {
+	tfs = make(map[string]*[fieldCount]int)
+	for f, name := range searchFields {
+		ts := terms(string(b.Get(name)))
+		lengths[f] = len(ts)
+		for _, t := range ts {
+			counts, ok := tfs[t]
+			if !ok {
+				counts = &[fieldCount]int{}
+				tfs[t] = counts
+			}
+			counts[f]++
+		}
+	}
+	return
+}
+
+func encodeCounts(counts [fieldCount]int) []byte {
+	v := make([]byte, 0, fieldCount*binary.MaxVarintLen32)
+	buf := make([]byte, binary.MaxVarintLen32)
+	for _, c := range counts {
+		n := binary.PutUvarint(buf, uint64(c))
+		v = append(v, buf[:n]...)
+	}
+	return v
+}
+
+func decodeCounts(v []byte) (counts [fieldCount]int) {
+	for f := range counts {
+		c, n := binary.Uvarint(v)
+		if n <= 0 {
+			break
+		}
+		counts[f] = int(c)
+		v = v[n:]
+	}
+	return
+}
@@ -0,0 +1,117 @@
+package resources
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// searchTitles titles of articles matching query, sorted
+func searchTitles(t *testing.T, query string) []string {
+	result, err := SearchArticles("", query, SortByPosition, 0, 0)
+	if err != nil {
+		t.Fatalf("SearchArticles(%q): %v", query, err)
+	}
+	titles := []string{}
+	for _, h := range result.Hits {
+		titles = append(titles, h.Title)
+	}
+	sort.Strings(titles)
+	return titles
+}
+
+func TestSearchArticles(t *testing.T) {
+	openTestDatabase(t)
+	for _, title := range []string{"apple pie", "apple tart", "cherry pie"} {
+		createArticle(t, RootArticleID, title)
+	}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"apple", []string{"apple pie", "apple tart"}},
+		{"apple pie", []string{"apple pie"}},
+		{`"pie apple"`, []string{}},
+		{"apple OR cherry", []string{"apple pie", "apple tart", "cherry pie"}},
+		{"pie -apple", []string{"cherry pie"}},
+		{"pie NOT (apple OR cherry)", []string{}},
+		{"title:tar", []string{"apple tart"}},
+		{"banana", []string{}},
+		{"pie NOT banana", []string{"apple pie", "cherry pie"}},
+		// operands without any term are no constraint
+		{"pie -!!", []string{"apple pie", "cherry pie"}},
+		{"cherry OR !!", []string{"cherry pie"}},
+		{"(apple OR !!) tart", []string{"apple tart"}},
+		{"!!", []string{}},
+	}
+	for _, tt := range tests {
+		if got := searchTitles(t, tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("search %q = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchStats(t *testing.T) {
+	openTestDatabase(t)
+	a := createArticle(t, RootArticleID, "one two")
+	createArticle(t, RootArticleID, "three")
+	a.Content = "four five six"
+	if err := a.Update(false, false, true, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.MoveToTrash(); err != nil {
+		t.Fatal(err)
+	}
+
+	db.View(func(tx Tx) error {
+		checkSearchStats(t, tx)
+		return nil
+	})
+}
+
+func TestKeepSearchStats(t *testing.T) {
+	openTestDatabase(t)
+	createArticle(t, RootArticleID, "one two")
+	createArticle(t, RootArticleID, "three")
+
+	// a database of schema version 3 keeps no statistics
+	err := db.Update(func(tx Tx) error {
+		if err := tx.DeleteBucket(searchStatsCollectionName); err != nil {
+			return err
+		}
+		return tx.Bucket(metaCollectionName).Put(fSchemaVersion, []byte("3"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateDatabase(db); err != nil {
+		t.Fatal(err)
+	}
+	db.View(func(tx Tx) error {
+		if tx.Bucket(searchStatsCollectionName) == nil {
+			t.Fatal("search statistics are not kept after migration")
+		}
+		if v, _ := schemaVersion(tx); v != migrations[len(migrations)-1].version {
+			t.Errorf("schema version after migration = %d", v)
+		}
+		checkSearchStats(t, tx)
+		return nil
+	})
+}
+
+// checkSearchStats check that kept search statistics match the index
+func checkSearchStats(t *testing.T, tx Tx) {
+	n, lengths := searchStats(tx)
+	var counted [fieldCount]int
+	m := 0
+	tx.Bucket(searchDocsCollectionName).ForEach(func(_, v []byte) error {
+		m++
+		for f, l := range decodeCounts(v) {
+			counted[f] += l
+		}
+		return nil
+	})
+	if n != m || lengths != counted {
+		t.Errorf("searchStats = %d, %v, counted %d, %v", n, lengths, m, counted)
+	}
+}
@@ -0,0 +1,133 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// storage backends
+const (
+	// BoltBackend keeps data in a bolt database file
+	BoltBackend = "bolt"
+	// MemoryBackend keeps data in memory only, it is lost when the program
+	// exits. Useful for tests and for trying things out.
+	MemoryBackend = "memory"
+)
+
+// Storage a transactional store of nested buckets of sorted keys, all
+// resources are kept in. Its semantics follow bolt: buckets and values
+// share keys of their parent bucket, the value of a nested bucket is nil,
+// and nothing done in a function passed to Update is kept if it returns
+// an error.
+//
+// Storage is below article operations rather than made of them: each
+// operation changes an article along with its revisions, the children,
+// tag, link and search indexes and the trash in one transaction, and
+// encryption wraps a storage as a whole. A backend implementing get,
+// create, update, delete, children, search, export and restore would have
+// to repeat all of that, while one implementing Storage runs every
+// operation unchanged, as tests do on MemoryBackend.
+type Storage interface {
+	// View run fn in a read-only transaction
+	View(fn func(Tx) error) error
+	// Update run fn in a read-write transaction, transactions of Update
+	// are run one at a time
+	Update(fn func(Tx) error) error
+	// WriteTo write a consistent snapshot of all data to w, in the format
+	// of a bolt database file
+	WriteTo(w io.Writer) (int64, error)
+	Close() error
+}
+
+// Tx a storage transaction, top level keys can only be buckets
+type Tx interface {
+	// ID changes whenever a transaction is committed
+	ID() int
+	Bucket(name []byte) Bucket
+	CreateBucket(name []byte) (Bucket, error)
+	CreateBucketIfNotExists(name []byte) (Bucket, error)
+	DeleteBucket(name []byte) error
+	// ForEach call fn with name of each top level bucket, in order
+	ForEach(fn func(name []byte) error) error
+}
+
+// Bucket a collection of keys and values, or nested buckets
+type Bucket interface {
+	// Get returns nil if key does not exist or is a nested bucket, the
+	// value is only valid in the transaction
+	Get(key []byte) []byte
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	Bucket(name []byte) Bucket
+	CreateBucket(name []byte) (Bucket, error)
+	CreateBucketIfNotExists(name []byte) (Bucket, error)
+	DeleteBucket(name []byte) error
+	// ForEach call fn for each key in order, v is nil for nested buckets
+	ForEach(fn func(k, v []byte) error) error
+	Cursor() Cursor
+	Sequence() uint64
+	SetSequence(v uint64) error
+	NextSequence() (uint64, error)
+}
+
+// Cursor iterate keys of a bucket in order, methods return a nil key
+// when there are no more keys
+type Cursor interface {
+	First() (key, value []byte)
+	Last() (key, value []byte)
+	Next() (key, value []byte)
+	Prev() (key, value []byte)
+	// Seek move to key, or the next key if it does not exist
+	Seek(seek []byte) (key, value []byte)
+}
+
+// storage errors, shared by backends
+var (
+	ErrBucketExists      = errors.New("bucket already exists")
+	ErrBucketNotFound    = errors.New("bucket not found")
+	ErrIncompatibleValue = errors.New("incompatible value")
+	ErrKeyRequired       = errors.New("key required")
+	ErrTxNotWritable     = errors.New("tx not writable")
+)
+
+// OpenStorage open storage of backend, path is the database file of bolt
+// backend and ignored by memory backend
+func OpenStorage(backend, path string) (Storage, error) {
+	switch backend {
+	case BoltBackend, "":
+		return openBoltStorage(path)
+	case MemoryBackend:
+		return newMemoryStorage(), nil
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", backend)
+}
+
+// countKeys return number of keys in b
+func countKeys(b Bucket) int {
+	n := 0
+	b.ForEach(func(_, _ []byte) error {
+		n++
+		return nil
+	})
+	return n
+}
+
+// copyStorage copy all buckets of src into dst
+func copyStorage(dst, src Storage) error {
+	return src.View(func(stx Tx) error {
+		return dst.Update(func(dtx Tx) error {
+			return stx.ForEach(func(k []byte) error {
+				y, err := dtx.CreateBucket(k)
+				if err != nil {
+					return err
+				}
+				x := stx.Bucket(k)
+				if err = y.SetSequence(x.Sequence()); err != nil {
+					return err
+				}
+				return copyBucket(y, x)
+			})
+		})
+	})
+}
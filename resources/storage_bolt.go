@@ -0,0 +1,211 @@
+package resources
+
+import (
+	"io"
+	"os"
+
+	"github.com/boltdb/bolt"
+)
+
+// boltStorage Storage in a bolt database file
+type boltStorage struct {
+	db   *bolt.DB
+	path string
+}
+
+func openBoltStorage(path string) (*boltStorage, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &boltStorage{db: db, path: path}, nil
+}
+
+// rename move the database file to path. Bolt reads the file by its path
+// at times, so it is reopened there. No transaction may run meanwhile.
+func (s *boltStorage) rename(path string) error {
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	err := os.Rename(s.path, path)
+	if err == nil {
+		s.path = path
+	}
+	db, oerr := bolt.Open(s.path, 0600, nil)
+	if oerr != nil {
+		return oerr
+	}
+	s.db = db
+	return err
+}
+
+// compact rewrite the database file with live data only. Bolt keeps pages
+// it no longer uses as they were, compacting drops what they held.
+// No transaction may run meanwhile.
+func (s *boltStorage) compact() error {
+	tmp := s.path + ".compact"
+	c, err := openBoltStorage(tmp)
+	if err != nil {
+		return err
+	}
+	if err = copyStorage(c, s); err != nil {
+		c.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err = c.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err = s.db.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+	}
+	// reopen whichever file is in place
+	db, oerr := bolt.Open(s.path, 0600, nil)
+	if oerr != nil {
+		return oerr
+	}
+	s.db = db
+	return err
+}
+
+func (s *boltStorage) View(fn func(Tx) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+func (s *boltStorage) Update(fn func(Tx) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+func (s *boltStorage) WriteTo(w io.Writer) (n int64, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	return
+}
+
+func (s *boltStorage) Close() error {
+	return s.db.Close()
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (t boltTx) ID() int {
+	return t.tx.ID()
+}
+
+func (t boltTx) Bucket(name []byte) Bucket {
+	return wrapBoltBucket(t.tx.Bucket(name))
+}
+
+func (t boltTx) CreateBucket(name []byte) (Bucket, error) {
+	b, err := t.tx.CreateBucket(name)
+	return wrapBoltBucket(b), boltError(err)
+}
+
+func (t boltTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	b, err := t.tx.CreateBucketIfNotExists(name)
+	return wrapBoltBucket(b), boltError(err)
+}
+
+func (t boltTx) DeleteBucket(name []byte) error {
+	return boltError(t.tx.DeleteBucket(name))
+}
+
+func (t boltTx) ForEach(fn func(name []byte) error) error {
+	return t.tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		return fn(name)
+	})
+}
+
+type boltBucket struct {
+	b *bolt.Bucket
+}
+
+// wrapBoltBucket keeps a missing bucket nil, rather than a non-nil
+// interface holding a nil pointer
+func wrapBoltBucket(b *bolt.Bucket) Bucket {
+	if b == nil {
+		return nil
+	}
+	return boltBucket{b}
+}
+
+func (b boltBucket) Get(key []byte) []byte {
+	return b.b.Get(key)
+}
+
+func (b boltBucket) Put(key, value []byte) error {
+	return boltError(b.b.Put(key, value))
+}
+
+func (b boltBucket) Delete(key []byte) error {
+	return boltError(b.b.Delete(key))
+}
+
+func (b boltBucket) Bucket(name []byte) Bucket {
+	return wrapBoltBucket(b.b.Bucket(name))
+}
+
+func (b boltBucket) CreateBucket(name []byte) (Bucket, error) {
+	c, err := b.b.CreateBucket(name)
+	return wrapBoltBucket(c), boltError(err)
+}
+
+func (b boltBucket) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	c, err := b.b.CreateBucketIfNotExists(name)
+	return wrapBoltBucket(c), boltError(err)
+}
+
+func (b boltBucket) DeleteBucket(name []byte) error {
+	return boltError(b.b.DeleteBucket(name))
+}
+
+func (b boltBucket) ForEach(fn func(k, v []byte) error) error {
+	return b.b.ForEach(fn)
+}
+
+func (b boltBucket) Cursor() Cursor {
+	return b.b.Cursor()
+}
+
+func (b boltBucket) Sequence() uint64 {
+	return b.b.Sequence()
+}
+
+func (b boltBucket) SetSequence(v uint64) error {
+	return boltError(b.b.SetSequence(v))
+}
+
+func (b boltBucket) NextSequence() (uint64, error) {
+	n, err := b.b.NextSequence()
+	return n, boltError(err)
+}
+
+// boltError translate bolt errors into storage errors
+func boltError(err error) error {
+	switch err {
+	case bolt.ErrBucketExists:
+		return ErrBucketExists
+	case bolt.ErrBucketNotFound:
+		return ErrBucketNotFound
+	case bolt.ErrIncompatibleValue:
+		return ErrIncompatibleValue
+	case bolt.ErrKeyRequired, bolt.ErrBucketNameRequired:
+		return ErrKeyRequired
+	case bolt.ErrTxNotWritable:
+		return ErrTxNotWritable
+	}
+	return err
+}
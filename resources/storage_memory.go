@@ -0,0 +1,359 @@
+package resources
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+)
+
+// memoryStorage Storage in memory. Committed buckets are never changed:
+// a read-write transaction copies each bucket it reaches before changing
+// it, and replaces the committed root when it succeeds, so readers keep a
+// consistent snapshot and failed transactions leave nothing behind.
+type memoryStorage struct {
+	// writer serializes read-write transactions
+	writer sync.Mutex
+
+	mu   sync.RWMutex
+	root *memBucket
+	txID int
+	// gen last generation of read-write transactions
+	gen uint64
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{root: newMemBucket(0)}
+}
+
+func (s *memoryStorage) View(fn func(Tx) error) error {
+	s.mu.RLock()
+	tx := &memTx{id: s.txID}
+	tx.root = s.root.copyFor(tx)
+	s.mu.RUnlock()
+	return fn(tx)
+}
+
+func (s *memoryStorage) Update(fn func(Tx) error) error {
+	s.writer.Lock()
+	defer s.writer.Unlock()
+
+	s.mu.RLock()
+	s.gen++
+	tx := &memTx{writable: true, gen: s.gen, id: s.txID + 1}
+	tx.root = s.root.copyFor(tx)
+	s.mu.RUnlock()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.root = tx.root
+	s.txID = tx.id
+	s.mu.Unlock()
+	return nil
+}
+
+// WriteTo write a snapshot through a temporary bolt database file
+func (s *memoryStorage) WriteTo(w io.Writer) (int64, error) {
+	f, err := ioutil.TempFile("", "notes-export-")
+	if err != nil {
+		return 0, err
+	}
+	name := f.Name()
+	f.Close()
+	defer os.Remove(name)
+
+	b, err := openBoltStorage(name)
+	if err != nil {
+		return 0, err
+	}
+	defer b.Close()
+
+	if err = copyStorage(b, s); err != nil {
+		return 0, err
+	}
+	return b.WriteTo(w)
+}
+
+func (s *memoryStorage) Close() error {
+	return nil
+}
+
+type memTx struct {
+	root     *memBucket
+	id       int
+	writable bool
+	// gen buckets of this generation belong to the transaction
+	gen uint64
+}
+
+func (t *memTx) ID() int {
+	return t.id
+}
+
+func (t *memTx) Bucket(name []byte) Bucket {
+	return t.root.Bucket(name)
+}
+
+func (t *memTx) CreateBucket(name []byte) (Bucket, error) {
+	return t.root.CreateBucket(name)
+}
+
+func (t *memTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	return t.root.CreateBucketIfNotExists(name)
+}
+
+func (t *memTx) DeleteBucket(name []byte) error {
+	return t.root.DeleteBucket(name)
+}
+
+func (t *memTx) ForEach(fn func(name []byte) error) error {
+	return t.root.ForEach(func(k, _ []byte) error {
+		return fn(k)
+	})
+}
+
+// memBucket a bucket in memory, values and nested buckets share keys
+type memBucket struct {
+	tx *memTx
+	// gen generation of the transaction which created this copy
+	gen uint64
+
+	keys    []string // sorted
+	values  map[string][]byte
+	buckets map[string]*memBucket
+	seq     uint64
+}
+
+func newMemBucket(gen uint64) *memBucket {
+	return &memBucket{
+		gen:     gen,
+		values:  make(map[string][]byte),
+		buckets: make(map[string]*memBucket),
+	}
+}
+
+// copyFor return b for use in tx, copied if tx may change it. Values and
+// nested buckets are shared until they are reached.
+func (b *memBucket) copyFor(tx *memTx) *memBucket {
+	if !tx.writable {
+		if b.tx == tx {
+			return b
+		}
+		c := *b
+		c.tx = tx
+		return &c
+	}
+	if b.gen == tx.gen {
+		return b
+	}
+	c := newMemBucket(tx.gen)
+	c.tx = tx
+	c.keys = append([]string(nil), b.keys...)
+	for k, v := range b.values {
+		c.values[k] = v
+	}
+	for k, v := range b.buckets {
+		c.buckets[k] = v
+	}
+	c.seq = b.seq
+	return c
+}
+
+func (b *memBucket) writable() error {
+	if !b.tx.writable {
+		return ErrTxNotWritable
+	}
+	return nil
+}
+
+func (b *memBucket) Get(key []byte) []byte {
+	return b.values[string(key)]
+}
+
+func (b *memBucket) Put(key, value []byte) error {
+	if err := b.writable(); err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return ErrKeyRequired
+	}
+	k := string(key)
+	if _, ok := b.buckets[k]; ok {
+		return ErrIncompatibleValue
+	}
+	if _, ok := b.values[k]; !ok {
+		b.insertKey(k)
+	}
+	b.values[k] = append(make([]byte, 0, len(value)), value...)
+	return nil
+}
+
+func (b *memBucket) Delete(key []byte) error {
+	if err := b.writable(); err != nil {
+		return err
+	}
+	k := string(key)
+	if _, ok := b.buckets[k]; ok {
+		return ErrIncompatibleValue
+	}
+	if _, ok := b.values[k]; ok {
+		delete(b.values, k)
+		b.removeKey(k)
+	}
+	return nil
+}
+
+func (b *memBucket) Bucket(name []byte) Bucket {
+	if c := b.bucket(string(name)); c != nil {
+		return c
+	}
+	return nil
+}
+
+// bucket return nested bucket name for use in transaction of b
+func (b *memBucket) bucket(name string) *memBucket {
+	c, ok := b.buckets[name]
+	if !ok {
+		return nil
+	}
+	c = c.copyFor(b.tx)
+	if b.tx.writable {
+		b.buckets[name] = c
+	}
+	return c
+}
+
+func (b *memBucket) CreateBucket(name []byte) (Bucket, error) {
+	if err := b.writable(); err != nil {
+		return nil, err
+	}
+	if len(name) == 0 {
+		return nil, ErrKeyRequired
+	}
+	k := string(name)
+	if _, ok := b.buckets[k]; ok {
+		return nil, ErrBucketExists
+	}
+	if _, ok := b.values[k]; ok {
+		return nil, ErrIncompatibleValue
+	}
+	c := newMemBucket(b.tx.gen)
+	c.tx = b.tx
+	b.buckets[k] = c
+	b.insertKey(k)
+	return c, nil
+}
+
+func (b *memBucket) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	if c := b.bucket(string(name)); c != nil {
+		return c, b.writable()
+	}
+	return b.CreateBucket(name)
+}
+
+func (b *memBucket) DeleteBucket(name []byte) error {
+	if err := b.writable(); err != nil {
+		return err
+	}
+	k := string(name)
+	if _, ok := b.buckets[k]; !ok {
+		if _, ok := b.values[k]; ok {
+			return ErrIncompatibleValue
+		}
+		return ErrBucketNotFound
+	}
+	delete(b.buckets, k)
+	b.removeKey(k)
+	return nil
+}
+
+func (b *memBucket) ForEach(fn func(k, v []byte) error) error {
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *memBucket) Cursor() Cursor {
+	return &memCursor{b: b}
+}
+
+func (b *memBucket) Sequence() uint64 {
+	return b.seq
+}
+
+func (b *memBucket) SetSequence(v uint64) error {
+	if err := b.writable(); err != nil {
+		return err
+	}
+	b.seq = v
+	return nil
+}
+
+func (b *memBucket) NextSequence() (uint64, error) {
+	if err := b.writable(); err != nil {
+		return 0, err
+	}
+	b.seq++
+	return b.seq, nil
+}
+
+func (b *memBucket) insertKey(k string) {
+	i := sort.SearchStrings(b.keys, k)
+	b.keys = append(b.keys, "")
+	copy(b.keys[i+1:], b.keys[i:])
+	b.keys[i] = k
+}
+
+func (b *memBucket) removeKey(k string) {
+	i := sort.SearchStrings(b.keys, k)
+	if i < len(b.keys) && b.keys[i] == k {
+		b.keys = append(b.keys[:i], b.keys[i+1:]...)
+	}
+}
+
+// memCursor remembers its key rather than an index, so that it keeps its
+// place when keys are added or deleted during iteration
+type memCursor struct {
+	b   *memBucket
+	key string
+}
+
+func (c *memCursor) at(i int) ([]byte, []byte) {
+	if i < 0 || i >= len(c.b.keys) {
+		return nil, nil
+	}
+	c.key = c.b.keys[i]
+	return []byte(c.key), c.b.values[c.key]
+}
+
+func (c *memCursor) First() ([]byte, []byte) {
+	return c.at(0)
+}
+
+func (c *memCursor) Last() ([]byte, []byte) {
+	return c.at(len(c.b.keys) - 1)
+}
+
+func (c *memCursor) Next() ([]byte, []byte) {
+	i := sort.SearchStrings(c.b.keys, c.key)
+	if i < len(c.b.keys) && c.b.keys[i] == c.key {
+		i++
+	}
+	return c.at(i)
+}
+
+func (c *memCursor) Prev() ([]byte, []byte) {
+	return c.at(sort.SearchStrings(c.b.keys, c.key) - 1)
+}
+
+func (c *memCursor) Seek(seek []byte) ([]byte, []byte) {
+	return c.at(sort.SearchStrings(c.b.keys, string(seek)))
+}
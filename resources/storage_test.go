@@ -0,0 +1,224 @@
+package resources
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// forEachBackend run test on a new storage of each backend
+func forEachBackend(t *testing.T, test func(t *testing.T, s Storage)) {
+	for _, backend := range []string{BoltBackend, MemoryBackend} {
+		t.Run(backend, func(t *testing.T) {
+			s, err := OpenStorage(backend, filepath.Join(t.TempDir(), "test.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			test(t, s)
+		})
+	}
+}
+
+func TestStorageUpdate(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		err := s.Update(func(tx Tx) error {
+			b, err := tx.CreateBucket([]byte("b"))
+			if err != nil {
+				return err
+			}
+			for _, k := range []string{"c", "a", "b"} {
+				if err := b.Put([]byte(k), []byte("v"+k)); err != nil {
+					return err
+				}
+			}
+			_, err = b.CreateBucket([]byte("sub"))
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		failed := errors.New("failed")
+		err = s.Update(func(tx Tx) error {
+			b := tx.Bucket([]byte("b"))
+			b.Put([]byte("a"), []byte("changed"))
+			b.Delete([]byte("b"))
+			tx.CreateBucket([]byte("other"))
+			return failed
+		})
+		if err != failed {
+			t.Fatalf("Update = %v, want the error of fn", err)
+		}
+
+		s.View(func(tx Tx) error {
+			if tx.Bucket([]byte("other")) != nil {
+				t.Error("bucket of a failed transaction was kept")
+			}
+			b := tx.Bucket([]byte("b"))
+			var keys []string
+			b.ForEach(func(k, v []byte) error {
+				keys = append(keys, string(k))
+				if (v == nil) != (string(k) == "sub") {
+					t.Errorf("value of %q = %q", k, v)
+				}
+				return nil
+			})
+			if got := strings.Join(keys, ","); got != "a,b,c,sub" {
+				t.Errorf("keys = %s, want a,b,c,sub", got)
+			}
+			if v := b.Get([]byte("a")); string(v) != "va" {
+				t.Errorf("Get(a) = %q after a failed transaction", v)
+			}
+			if b.Get([]byte("sub")) != nil {
+				t.Error("Get of a nested bucket is not nil")
+			}
+			if err := b.Put([]byte("x"), nil); err != ErrTxNotWritable {
+				t.Errorf("Put in View = %v, want ErrTxNotWritable", err)
+			}
+			return nil
+		})
+	})
+}
+
+func TestStorageErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		s.Update(func(tx Tx) error {
+			b, err := tx.CreateBucket([]byte("b"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tx.CreateBucket([]byte("b")); err != ErrBucketExists {
+				t.Errorf("CreateBucket of an existing bucket = %v", err)
+			}
+			if err := tx.DeleteBucket([]byte("none")); err != ErrBucketNotFound {
+				t.Errorf("DeleteBucket of a missing bucket = %v", err)
+			}
+			if err := b.Put(nil, []byte("v")); err != ErrKeyRequired {
+				t.Errorf("Put of an empty key = %v", err)
+			}
+			b.Put([]byte("v"), []byte("v"))
+			if _, err := b.CreateBucket([]byte("v")); err != ErrIncompatibleValue {
+				t.Errorf("CreateBucket over a value = %v", err)
+			}
+			return nil
+		})
+	})
+}
+
+func TestStorageCursor(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		s.Update(func(tx Tx) error {
+			b, _ := tx.CreateBucket([]byte("b"))
+			for _, k := range []string{"b", "d", "f"} {
+				b.Put([]byte(k), []byte(k))
+			}
+			return nil
+		})
+		s.View(func(tx Tx) error {
+			c := tx.Bucket([]byte("b")).Cursor()
+			steps := []struct {
+				name string
+				k    []byte
+				want string
+			}{
+				{"First", nil, "b"},
+				{"Next", nil, "d"},
+				{"Seek", []byte("e"), "f"},
+				{"Next", nil, ""},
+				{"Last", nil, "f"},
+				{"Prev", nil, "d"},
+				{"Seek", []byte("a"), "b"},
+				{"Prev", nil, ""},
+				{"Seek", []byte("g"), ""},
+			}
+			for _, step := range steps {
+				var k []byte
+				switch step.name {
+				case "First":
+					k, _ = c.First()
+				case "Last":
+					k, _ = c.Last()
+				case "Next":
+					k, _ = c.Next()
+				case "Prev":
+					k, _ = c.Prev()
+				case "Seek":
+					k, _ = c.Seek(step.k)
+				}
+				if string(k) != step.want {
+					t.Errorf("%s(%s) = %q, want %q", step.name, step.k, k, step.want)
+				}
+			}
+			return nil
+		})
+	})
+}
+
+func TestStorageSequence(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		s.Update(func(tx Tx) error {
+			b, _ := tx.CreateBucket([]byte("b"))
+			if err := b.SetSequence(5); err != nil {
+				t.Fatal(err)
+			}
+			if n, err := b.NextSequence(); err != nil || n != 6 {
+				t.Errorf("NextSequence = %d, %v, want 6", n, err)
+			}
+			return nil
+		})
+		s.View(func(tx Tx) error {
+			if n := tx.Bucket([]byte("b")).Sequence(); n != 6 {
+				t.Errorf("Sequence = %d after commit, want 6", n)
+			}
+			return nil
+		})
+	})
+}
+
+func TestStorageSnapshot(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Storage) {
+		s.Update(func(tx Tx) error {
+			b, _ := tx.CreateBucket([]byte("b"))
+			return b.Put([]byte("k"), []byte("old"))
+		})
+		var id int
+		s.View(func(tx Tx) error {
+			id = tx.ID()
+			return nil
+		})
+		s.Update(func(tx Tx) error {
+			return tx.Bucket([]byte("b")).Put([]byte("k"), []byte("new"))
+		})
+		s.View(func(tx Tx) error {
+			if tx.ID() == id {
+				t.Error("ID is unchanged by a commit")
+			}
+			return nil
+		})
+
+		// a snapshot is a bolt database, of the same data
+		var buf bytes.Buffer
+		if _, err := s.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "snapshot.db")
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0600); err != nil {
+			t.Fatal(err)
+		}
+		snapshot, err := OpenStorage(BoltBackend, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer snapshot.Close()
+		snapshot.View(func(tx Tx) error {
+			if v := tx.Bucket([]byte("b")).Get([]byte("k")); string(v) != "new" {
+				t.Errorf("snapshot has %q, want new", v)
+			}
+			return nil
+		})
+	})
+}
@@ -0,0 +1,329 @@
+package resources
+
+import (
+	"sort"
+	"sync"
+	"unicode"
+)
+
+// maxRecentVisits number of recently visited articles remembered
+const maxRecentVisits = 50
+
+// fuzzy match scores
+const (
+	scoreMatch       = 16
+	scoreWordStart   = 8
+	scoreConsecutive = 4
+	scoreGap         = -1
+	scoreTypo        = -12
+	// at most scoreRecent is added for the latest visited article
+	scoreRecent = 24
+)
+
+// SwitcherHit an article matched by a quick switcher query
+type SwitcherHit struct {
+	ID    string                 `json:"id"`
+	Title string                 `json:"title"`
+	Path  []*SwitcherHitAncestor `json:"path"`
+	Score int                    `json:"score"`
+}
+
+// SwitcherHitAncestor an ancestor of a hit, to tell apart same titles
+type SwitcherHitAncestor struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// titleCache titles and parents of all articles, for quick switching
+var titleCache struct {
+	sync.Mutex
+	// storage and id of the last transaction committed when entries were
+	// loaded
+	storage Storage
+	txID    int
+	entries map[string]*titleEntry
+	// recent ids of visited articles, the latest last
+	recent map[recentKey][]string
+}
+
+// recentKey visits are remembered per notebook and session
+type recentKey struct {
+	storage Storage
+	session string
+}
+
+type titleEntry struct {
+	title, parent string
+	folded        []rune
+	wordStarts    []bool
+}
+
+// RecordVisit remember that an article of the notebook in use was visited
+// in session
+func RecordVisit(session, id string) {
+	titleCache.Lock()
+	defer titleCache.Unlock()
+	if titleCache.recent == nil {
+		titleCache.recent = make(map[recentKey][]string)
+	}
+	key := recentKey{db, session}
+	recent := titleCache.recent[key]
+	for i, v := range recent {
+		if v == id {
+			recent = append(recent[:i], recent[i+1:]...)
+			break
+		}
+	}
+	recent = append(recent, id)
+	if len(recent) > maxRecentVisits {
+		recent = recent[1:]
+	}
+	titleCache.recent[key] = recent
+}
+
+// SwitchTo rank articles by how well their titles fuzzily match query,
+// tolerating a typo, articles recently visited in session are boosted.
+// Recently visited articles are returned for an empty query.
+// Articles hidden by locked private articles are left out.
+func SwitchTo(session, query string, limit int) ([]*SwitcherHit, error) {
+	titleCache.Lock()
+	defer titleCache.Unlock()
+	if err := loadTitleCache(); err != nil {
+		return nil, err
+	}
+
+	recent := titleCache.recent[recentKey{db, session}]
+	recency := make(map[string]int, len(recent))
+	for i, id := range recent {
+		recency[id] = scoreRecent * (i + 1) / len(recent)
+	}
+
+	hits := []*SwitcherHit{}
+	q := foldString(query)
+	for id, e := range titleCache.entries {
+		score := recency[id]
+		if len(q) > 0 {
+			s, ok := fuzzyScore(q, e)
+			if !ok {
+				continue
+			}
+			score += s
+		} else if score == 0 {
+			continue
+		}
+		hits = append(hits, &SwitcherHit{ID: id, Title: e.title, Score: score})
+	}
+	hits, err := visibleHits(session, hits)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if len(hits[i].Title) != len(hits[j].Title) {
+			return len(hits[i].Title) < len(hits[j].Title)
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	for _, h := range hits {
+		h.Path = ancestorPath(h.ID)
+	}
+	return hits, nil
+}
+
+// visibleHits leave out hits hidden from session by locked private articles
+func visibleHits(session string, hits []*SwitcherHit) ([]*SwitcherHit, error) {
+	visible := hits[:0]
+	err := db.View(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		pv := newPrivacy(c, session)
+		for _, h := range hits {
+			if !pv.hidden(h.ID) {
+				visible = append(visible, h)
+			}
+		}
+		return nil
+	})
+	return visible, err
+}
+
+// fuzzyScore score query against a title, query runes must appear in
+// title in order, or all but one of them when query is long enough
+func fuzzyScore(q []rune, e *titleEntry) (int, bool) {
+	if s, ok := subsequenceScore(q, e, len(e.folded)); ok {
+		return s, true
+	}
+	if len(q) < 4 {
+		return 0, false
+	}
+
+	// tolerate one typo, by skipping one rune of query, the rest must
+	// match closely, or nearly anything would match
+	best, found := 0, false
+	maxSpan := 2 * len(q)
+	skipped := make([]rune, len(q)-1)
+	for i := range q {
+		copy(skipped, q[:i])
+		copy(skipped[i:], q[i+1:])
+		if s, ok := subsequenceScore(skipped, e, maxSpan); ok && (!found || s > best) {
+			best, found = s, true
+		}
+	}
+	return best + scoreTypo, found
+}
+
+// subsequenceScore find q in title as a subsequence, then look backward
+// from where it ends for a tighter match, and score it. The match fails
+// if it spans more than maxSpan runes.
+func subsequenceScore(q []rune, e *titleEntry, maxSpan int) (int, bool) {
+	t := e.folded
+	if len(q) > len(t) {
+		return 0, false
+	}
+
+	// forward
+	qi, end := 0, -1
+	for ti := 0; ti < len(t); ti++ {
+		if t[ti] == q[qi] {
+			qi++
+			if qi == len(q) {
+				end = ti
+				break
+			}
+		}
+	}
+	if end == -1 {
+		return 0, false
+	}
+
+	// backward
+	qi = len(q) - 1
+	start := end
+	for ti := end; ti >= 0; ti-- {
+		if t[ti] == q[qi] {
+			qi--
+			if qi < 0 {
+				start = ti
+				break
+			}
+		}
+	}
+
+	if end-start+1 > maxSpan {
+		return 0, false
+	}
+
+	// score
+	score := 0
+	qi = 0
+	last := -2
+	for ti := start; ti <= end && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			score += scoreGap
+			continue
+		}
+		score += scoreMatch
+		if e.wordStarts[ti] {
+			score += scoreWordStart
+		}
+		if last == ti-1 {
+			score += scoreConsecutive
+		}
+		last = ti
+		qi++
+	}
+	if start == 0 {
+		score += scoreWordStart
+	}
+	return score, true
+}
+
+// ancestorPath return ancestors of article, from the root
+func ancestorPath(id string) []*SwitcherHitAncestor {
+	var path []*SwitcherHitAncestor
+	seen := map[string]bool{id: true}
+	for e := titleCache.entries[id]; e != nil && e.parent != ""; {
+		if seen[e.parent] {
+			// broken tree
+			break
+		}
+		seen[e.parent] = true
+		p := titleCache.entries[e.parent]
+		if p == nil {
+			break
+		}
+		path = append([]*SwitcherHitAncestor{{ID: e.parent, Title: p.title}},
+			path...)
+		e = p
+	}
+	return path
+}
+
+// loadTitleCache load titles, unless they are loaded and the database
+// has not been changed since
+func loadTitleCache() error {
+	return db.View(func(tx Tx) error {
+		if titleCache.entries != nil && titleCache.storage == db &&
+			titleCache.txID == tx.ID() {
+			return nil
+		}
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		entries := make(map[string]*titleEntry)
+		err = c.ForEach(func(k, _ []byte) error {
+			b := c.Bucket(k)
+			title := string(b.Get(fTitle))
+			e := &titleEntry{title: title, parent: string(b.Get(fParent))}
+			e.folded, e.wordStarts = foldTitle(title)
+			entries[string(k)] = e
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		titleCache.entries, titleCache.txID = entries, tx.ID()
+		titleCache.storage = db
+		return nil
+	})
+}
+
+// foldTitle fold runes of title for matching, and mark which of them
+// start words
+func foldTitle(title string) (folded []rune, wordStarts []bool) {
+	prev := ' '
+	for _, r := range title {
+		start := !(unicode.IsLetter(prev) || unicode.IsDigit(prev)) ||
+			(unicode.IsLower(prev) && unicode.IsUpper(r)) || isCJK(r)
+		for _, f := range foldRune(r) {
+			folded = append(folded, f)
+			wordStarts = append(wordStarts, start)
+			start = false
+		}
+		prev = r
+	}
+	return
+}
+
+func foldString(s string) []rune {
+	var out []rune
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		for _, f := range foldRune(r) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package resources
+
+import (
+	"reflect"
+	"testing"
+)
+
+// switchTitles titles of articles a quick switcher query finds, in order
+func switchTitles(t *testing.T, session, query string) []string {
+	hits, err := SwitchTo(session, query, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := []string{}
+	for _, h := range hits {
+		titles = append(titles, h.Title)
+	}
+	return titles
+}
+
+func TestSwitchTo(t *testing.T) {
+	openTestDatabase(t)
+	for _, title := range []string{"Go Notes", "golang tips", "Shopping list",
+		"Café"} {
+		createArticle(t, RootArticleID, title)
+	}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// starts of words rank higher than runes inside them
+		{"gn", []string{"Go Notes", "golang tips"}},
+		{"GO", []string{"Go Notes", "golang tips"}},
+		{"tips", []string{"golang tips"}},
+		{"cafe", []string{"Café"}},
+		// a typo is tolerated in long enough queries
+		{"lisz", []string{"Shopping list"}},
+		{"lsz", []string{}},
+		{"xyz", []string{}},
+		// nothing is visited yet
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if got := switchTitles(t, "", tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SwitchTo(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSwitchToRecent(t *testing.T) {
+	openTestDatabase(t)
+	a := createArticle(t, RootArticleID, "Go Notes")
+	b := createArticle(t, RootArticleID, "golang tips")
+	RecordVisit("s1", b.ID)
+	RecordVisit("s1", a.ID)
+	RecordVisit("s1", b.ID)
+
+	// visits of a session boost only its own queries
+	if got, want := switchTitles(t, "s1", "g"), []string{"golang tips", "Go Notes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SwitchTo of s1 = %q, want %q", got, want)
+	}
+	if got, want := switchTitles(t, "s2", "g"), []string{"Go Notes", "golang tips"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SwitchTo of s2 = %q, want %q", got, want)
+	}
+	if got, want := switchTitles(t, "s1", ""), []string{"golang tips", "Go Notes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recent of s1 = %q, want %q", got, want)
+	}
+	if got := switchTitles(t, "s2", ""); len(got) != 0 {
+		t.Errorf("recent of s2 = %q", got)
+	}
+
+	// nor are they of another notebook
+	openTestDatabase(t)
+	if got := switchTitles(t, "s1", ""); len(got) != 0 {
+		t.Errorf("recent of s1 in another notebook = %q", got)
+	}
+}
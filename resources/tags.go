@@ -0,0 +1,233 @@
+package resources
+
+import (
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// tagsCollectionName names the index from tag to ids of articles
+var tagsCollectionName = []byte("Tags")
+
+// ErrTagNotFound returned when no article has the tag
+var ErrTagNotFound = errors.New("tag not found")
+
+// TagCount a tag and number of articles having it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+	// Version changes whenever an article having the tag changes, or
+	// the tag is given to or taken from an article
+	Version uint64 `json:"version"`
+}
+
+// ParseTags split comma separated tags, empty and duplicated ones are dropped
+func ParseTags(s string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, t := range strings.Split(s, ",") {
+		t = normalizeTag(t)
+		if t != "" && !seen[t] {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// ListTags list all tags with number of articles, in alphabetical order,
+// except articles hidden by locked private articles
+func ListTags(session string) (tags []*TagCount, err error) {
+	err = db.View(func(tx Tx) error {
+		t := tx.Bucket(tagsCollectionName)
+		if t == nil {
+			return nil
+		}
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		pv := newPrivacy(c, session)
+		return t.ForEach(func(k, _ []byte) error {
+			count := 0
+			t.Bucket(k).ForEach(func(id, _ []byte) error {
+				if !pv.hidden(string(id)) {
+					count++
+				}
+				return nil
+			})
+			if count > 0 {
+				tags = append(tags, &TagCount{Tag: string(k), Count: count,
+					Version: tagVersion(c, t.Bucket(k))})
+			}
+			return nil
+		})
+	})
+	return
+}
+
+// TagVersion get version of tag, see TagCount
+func TagVersion(tag string) (version uint64, err error) {
+	err = db.View(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		t := tx.Bucket(tagsCollectionName)
+		if t == nil || t.Bucket([]byte(normalizeTag(tag))) == nil {
+			return ErrTagNotFound
+		}
+		version = tagVersion(c, t.Bucket([]byte(normalizeTag(tag))))
+		return nil
+	})
+	return
+}
+
+// tagVersion hash ids and versions of articles in index entry x of a tag,
+// in 53 bits to be exact as a number in JavaScript
+func tagVersion(c, x Bucket) uint64 {
+	h := fnv.New64a()
+	x.ForEach(func(k, _ []byte) error {
+		h.Write(k)
+		if b := c.Bucket(k); b != nil {
+			h.Write(encodeVersion(decodeVersion(b.Get(fVersion))))
+		}
+		return nil
+	})
+	return h.Sum64() & (1<<53 - 1)
+}
+
+// GetArticlesByTag list articles having tag, except those hidden by locked
+// private articles
+func GetArticlesByTag(session, tag string) (articles []*ArticleTitle, err error) {
+	err = db.View(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		t := tx.Bucket(tagsCollectionName)
+		if t == nil || t.Bucket([]byte(normalizeTag(tag))) == nil {
+			return ErrTagNotFound
+		}
+		pv := newPrivacy(c, session)
+		return t.Bucket([]byte(normalizeTag(tag))).ForEach(func(k, _ []byte) error {
+			if b := c.Bucket(k); b != nil && !pv.hidden(string(k)) {
+				articles = append(articles, articleTitle(k, b))
+			}
+			return nil
+		})
+	})
+	return
+}
+
+// RenameTag rename tag in all articles, except those hidden by locked
+// private articles, tags are merged if to already exists
+func RenameTag(session, from, to string) error {
+	from, to = normalizeTag(from), normalizeTag(to)
+	if to == "" {
+		return errors.New("empty tag")
+	}
+	if strings.Contains(to, ",") {
+		return errors.New("tag cannot contain comma")
+	}
+
+	return db.Update(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		t := tx.Bucket(tagsCollectionName)
+		if t == nil || t.Bucket([]byte(from)) == nil {
+			return ErrTagNotFound
+		}
+
+		pv := newPrivacy(c, session)
+		var ids [][]byte
+		t.Bucket([]byte(from)).ForEach(func(k, _ []byte) error {
+			if !pv.hidden(string(k)) {
+				ids = append(ids, append([]byte{}, k...))
+			}
+			return nil
+		})
+		if len(ids) == 0 {
+			return ErrTagNotFound
+		}
+		for _, id := range ids {
+			b := c.Bucket(id)
+			if b == nil {
+				continue
+			}
+			if err := unindexArticle(tx, id, b); err != nil {
+				return err
+			}
+			// keep the version being replaced
+			if err := saveRevision(tx, string(id), b); err != nil {
+				return err
+			}
+			tags := decodeTags(b.Get(fTags))
+			for i := range tags {
+				if tags[i] == from {
+					tags[i] = to
+				}
+			}
+			tags = ParseTags(strings.Join(tags, ","))
+			if err := b.Put(fTags, encodeTags(tags)); err != nil {
+				return err
+			}
+			if _, err := bumpVersion(b); err != nil {
+				return err
+			}
+			if err := indexArticle(tx, id, b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func indexTags(tx Tx, id []byte, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	t, err := tx.CreateBucketIfNotExists(tagsCollectionName)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		x, err := t.CreateBucketIfNotExists([]byte(tag))
+		if err != nil {
+			return err
+		}
+		if err = x.Put(id, []byte{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unindexTags(tx Tx, id []byte, tags []string) error {
+	for _, tag := range tags {
+		if err := removeFromIndex(tx, tagsCollectionName, tag, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func normalizeTag(tag string) string {
+	return strings.Join(strings.Fields(tag), " ")
+}
+
+func encodeTags(tags []string) []byte {
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+	return []byte(strings.Join(sorted, "\n"))
+}
+
+func decodeTags(v []byte) []string {
+	if len(v) == 0 {
+		return nil
+	}
+	return strings.Split(string(v), "\n")
+}
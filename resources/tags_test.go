@@ -0,0 +1,66 @@
+package resources
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// tagCounts tags with their counts visible to session
+func tagCounts(t *testing.T, session string) map[string]int {
+	tags, err := ListTags(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, tc := range tags {
+		counts[tc.Tag] = tc.Count
+	}
+	return counts
+}
+
+func TestRenameTag(t *testing.T) {
+	openTestDatabase(t)
+	a := createArticle(t, RootArticleID, "a")
+	private := createArticle(t, RootArticleID, "private")
+	sub := createArticle(t, private.ID, "sub")
+	for _, x := range []*Article{a, sub} {
+		x.Tags = []string{"old"}
+		if err := x.Update(false, false, false, false, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := private.SetPrivate("password"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tagCounts(t, "s1"), map[string]int{"old": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tags of a locked session = %v, want %v", got, want)
+	}
+
+	if err := RenameTag("s1", "old", "new"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetArticle(a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Tags, []string{"new"}) || got.Version != 3 {
+		t.Errorf("renamed article = %+v", got)
+	}
+	if old, err := got.AtVersion(2); err != nil || !reflect.DeepEqual(old.Tags, []string{"old"}) {
+		t.Errorf("revision before renaming = %+v, %v", old, err)
+	}
+	if got, err := GetArticle(sub.ID); err != nil || !reflect.DeepEqual(got.Tags, []string{"old"}) {
+		t.Errorf("article hidden while renaming = %+v, %v", got, err)
+	}
+	if err := RenameTag("s1", "old", "new"); err != ErrTagNotFound {
+		t.Errorf("renaming a tag of hidden articles only: %v", err)
+	}
+
+	if err := UnlockPrivate("s1", private.ID, "password", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tagCounts(t, "s1"), map[string]int{"new": 1, "old": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tags of an unlocked session = %v, want %v", got, want)
+	}
+}
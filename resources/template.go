@@ -0,0 +1,251 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fTemplate article field of a template, see SetTemplate
+var fTemplate = []byte("Template")
+
+// placeholderRegexp matches placeholders in templates: {{date}}, {{time}},
+// {{title}}, {{parent.title}}, and {{prompt:Name}} filled with answers of
+// the user
+var placeholderRegexp = regexp.MustCompile(`{{\s*([\w.]+)(?::\s*([^{}]*?))?\s*}}`)
+
+// ErrNotTemplate returned when creating from an article not a template
+var ErrNotTemplate = errors.New("article is not a template")
+
+// Template brief of a template
+type Template struct {
+	*ArticleTitle
+	// Prompts names of {{prompt:Name}} placeholders, in its subtree too
+	Prompts []string `json:"prompts"`
+}
+
+// SetTemplate mark article as a template, or not
+func (a *Article) SetTemplate(template bool) error {
+	return db.Update(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		b := c.Bucket([]byte(a.ID))
+		if b == nil {
+			return ErrArticleNotFound
+		}
+		if err = saveRevision(tx, a.ID, b); err != nil {
+			return err
+		}
+		if template {
+			err = b.Put(fTemplate, []byte{1})
+		} else {
+			err = b.Delete(fTemplate)
+		}
+		if err != nil {
+			return err
+		}
+		a.Template = template
+		a.Version, err = bumpVersion(b)
+		return err
+	})
+}
+
+// ListTemplates list templates by title, except those hidden by locked
+// private articles
+func ListTemplates(session string) (list []*Template, err error) {
+	err = db.View(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		p := newPrivacy(c, session)
+		return c.ForEach(func(k, _ []byte) error {
+			b := c.Bucket(k)
+			if b == nil || b.Get(fTemplate) == nil || p.hidden(string(k)) {
+				return nil
+			}
+			list = append(list, &Template{
+				ArticleTitle: articleTitle(k, b),
+				Prompts:      templatePrompts(tx, c, p, string(k), true),
+			})
+			return nil
+		})
+	})
+	sort.SliceStable(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Title) < strings.ToLower(list[j].Title)
+	})
+	return
+}
+
+// templatePrompts find names of prompts in template id, and its subtree if
+// subtree, in order of appearance
+func templatePrompts(tx Tx, c Bucket, p *privacy, id string,
+	subtree bool) []string {
+	prompts := []string{}
+	seen := make(map[string]bool)
+	walkTemplate(tx, p, id, subtree, func(id string) {
+		b := c.Bucket([]byte(id))
+		for _, f := range [][]byte{fTitle, fContent, fDiagram} {
+			for _, m := range placeholderRegexp.FindAllSubmatch(b.Get(f), -1) {
+				name := string(m[2])
+				if string(m[1]) == "prompt" && !seen[name] {
+					seen[name] = true
+					prompts = append(prompts, name)
+				}
+			}
+		}
+	})
+	return prompts
+}
+
+// walkTemplate run fn on id, and its descendants if subtree, parents
+// first. Those hidden by locked private articles are skipped.
+func walkTemplate(tx Tx, p *privacy, id string, subtree bool,
+	fn func(id string)) {
+	fn(id)
+	if !subtree {
+		return
+	}
+	for _, sub := range childrenOf(tx, id) {
+		if !p.hidden(sub) {
+			walkTemplate(tx, p, sub, true, fn)
+		}
+	}
+}
+
+// CreateFromTemplate create article a as a copy of template, with copies
+// of its sub-articles if subtree. Copies keep attachments and passwords of
+// private articles, but are not templates. Placeholders in titles,
+// contents and diagrams are filled, prompts with answers. Title and tags
+// of a are taken from template unless they are given.
+func (a *Article) CreateFromTemplate(session, template string, subtree bool,
+	answers map[string]string) error {
+	return db.Update(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		t := c.Bucket([]byte(template))
+		if t == nil {
+			return ErrArticleNotFound
+		}
+		if t.Get(fTemplate) == nil {
+			return ErrNotTemplate
+		}
+		p := newPrivacy(c, session)
+		for _, name := range templatePrompts(tx, c, p, template, subtree) {
+			if _, ok := answers[name]; !ok {
+				return fmt.Errorf("no answer to prompt %q", name)
+			}
+		}
+
+		// taken before creating, a may be created inside template
+		var ids []string
+		walkTemplate(tx, p, template, subtree, func(id string) {
+			ids = append(ids, id)
+		})
+
+		parent := c.Bucket([]byte(a.Parent))
+		if parent == nil {
+			return errors.New("parent article not exists")
+		}
+		f := &templateFiller{now: time.Now(), answers: answers}
+
+		// parents are copied first
+		copies := map[string]*Article{string(t.Get(fParent)): {
+			ID: a.Parent, Title: string(parent.Get(fTitle))}}
+		for _, id := range ids {
+			b := c.Bucket([]byte(id))
+			parent := copies[string(b.Get(fParent))]
+			sub := &Article{Parent: parent.ID, Tags: decodeTags(b.Get(fTags))}
+			if id == template {
+				sub.Title = a.Title
+				if a.Tags != nil {
+					sub.Tags = a.Tags
+				}
+			}
+			if sub.Title == "" {
+				sub.Title = f.fill(string(b.Get(fTitle)), "", parent.Title)
+			}
+			sub.Content = f.fill(string(b.Get(fContent)), sub.Title, parent.Title)
+			sub.Diagram = f.fill(string(b.Get(fDiagram)), sub.Title, parent.Title)
+			if err := sub.copyFrom(tx, c, id, f.now); err != nil {
+				return err
+			}
+			copies[id] = sub
+		}
+		*a = *copies[template]
+		return nil
+	})
+}
+
+// copyFrom create article a as a copy of article id, with fields of a in
+// place of those copied. The copy is not a template.
+func (a *Article) copyFrom(tx Tx, c Bucket, id string, now time.Time) error {
+	var err error
+	if a.ID, err = newID(); err != nil {
+		return err
+	}
+	if c.Bucket([]byte(a.ID)) != nil {
+		return errors.New("article already exists")
+	}
+	b, err := copyArticle(tx, c, id, a.ID, a.Parent, now)
+	if err != nil {
+		return err
+	}
+	for _, f := range []struct{ name, value []byte }{
+		{fTitle, []byte(a.Title)},
+		{fContent, []byte(a.Content)},
+		{fDiagram, []byte(a.Diagram)},
+		{fTags, encodeTags(a.Tags)},
+	} {
+		if err = b.Put(f.name, f.value); err != nil {
+			return err
+		}
+	}
+	if err = b.Delete(fTemplate); err != nil {
+		return err
+	}
+	a.CreatedAt, a.UpdatedAt, a.Version = now, now, 1
+	a.Private = b.Get(fPrivate) != nil
+	if err = indexArticle(tx, []byte(a.ID), b); err != nil {
+		return err
+	}
+	return addChild(tx, a.Parent, a.ID)
+}
+
+// templateFiller fill placeholders of templates
+type templateFiller struct {
+	now     time.Time
+	answers map[string]string
+}
+
+// fill placeholders in s of an article titled title, under parent titled
+// parentTitle. Unknown placeholders are kept as they are.
+func (f *templateFiller) fill(s, title, parentTitle string) string {
+	return placeholderRegexp.ReplaceAllStringFunc(s, func(p string) string {
+		m := placeholderRegexp.FindStringSubmatch(p)
+		switch m[1] {
+		case "date":
+			return f.now.Format("2006-01-02")
+		case "time":
+			return f.now.Format("15:04")
+		case "title":
+			if title != "" {
+				return title
+			}
+		case "parent.title":
+			return parentTitle
+		case "prompt":
+			if v, ok := f.answers[m[2]]; ok {
+				return v
+			}
+		}
+		return p
+	})
+}
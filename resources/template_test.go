@@ -0,0 +1,90 @@
+package resources
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFillTemplate(t *testing.T) {
+	f := &templateFiller{
+		now:     time.Date(2020, 3, 4, 5, 6, 0, 0, time.UTC),
+		answers: map[string]string{"Who": "Ann", "Empty": ""},
+	}
+	tests := []struct {
+		s, title, want string
+	}{
+		{"{{date}} {{ time }}", "t", "2020-03-04 05:06"},
+		{"{{title}} in {{parent.title}}", "t", "t in p"},
+		// a title is not filled with itself
+		{"{{title}}", "", "{{title}}"},
+		{"{{prompt:Who}}, {{ prompt: Who }}, [{{prompt:Empty}}]", "t", "Ann, Ann, []"},
+		{"{{prompt:Missing}} {{unknown}} {{date", "t", "{{prompt:Missing}} {{unknown}} {{date"},
+	}
+	for _, tt := range tests {
+		if got := f.fill(tt.s, tt.title, "p"); got != tt.want {
+			t.Errorf("fill(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestCreateFromTemplate(t *testing.T) {
+	openTestDatabase(t)
+	folder := createArticle(t, RootArticleID, "Meetings")
+	tpl := createArticle(t, RootArticleID, "Meeting {{prompt:Topic}}")
+	tpl.Content = "# {{title}} of {{parent.title}}\nwith {{prompt:Who}}"
+	tpl.Tags = []string{"meeting"}
+	if err := tpl.Update(false, false, true, false, true); err != nil {
+		t.Fatal(err)
+	}
+	sub := createArticle(t, tpl.ID, "Notes on {{prompt:Topic}}")
+	sub.Content = "{{parent.title}}"
+	if err := sub.Update(false, false, true, false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &Article{Parent: folder.ID}
+	if err := a.CreateFromTemplate("", tpl.ID, false, nil); err != ErrNotTemplate {
+		t.Errorf("creating from an article not a template: %v", err)
+	}
+	if err := tpl.SetTemplate(true); err != nil {
+		t.Fatal(err)
+	}
+	templates, err := ListTemplates("")
+	if err != nil || len(templates) != 1 ||
+		!reflect.DeepEqual(templates[0].Prompts, []string{"Topic", "Who"}) {
+		t.Fatalf("ListTemplates = %+v, %v", templates, err)
+	}
+	if err := a.CreateFromTemplate("", tpl.ID, true, map[string]string{"Topic": "x"}); err == nil {
+		t.Error("created without an answer to a prompt")
+	}
+
+	answers := map[string]string{"Topic": "Budget", "Who": "Bob"}
+	if err := a.CreateFromTemplate("", tpl.ID, true, answers); err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetArticle(a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Meeting Budget" || got.Content != "# Meeting Budget of Meetings\nwith Bob" ||
+		!reflect.DeepEqual(got.Tags, []string{"meeting"}) || got.Template {
+		t.Errorf("created article = %+v", got)
+	}
+	subs, err := got.GetSubArticles("")
+	if err != nil || len(subs) != 1 {
+		t.Fatalf("sub-articles = %v, %v", subs, err)
+	}
+	if s, _ := GetArticle(subs[0].ID); s.Title != "Notes on Budget" || s.Content != "Meeting Budget" {
+		t.Errorf("created sub-article = %+v", s)
+	}
+
+	// a given title is kept as it is
+	b := &Article{Parent: folder.ID, Title: "{{date}}"}
+	if err := b.CreateFromTemplate("", tpl.ID, false, answers); err != nil {
+		t.Fatal(err)
+	}
+	if b.Title != "{{date}}" || b.Content != "# {{date}} of Meetings\nwith Bob" {
+		t.Errorf("created with a title = %+v", b)
+	}
+}
@@ -0,0 +1,128 @@
+package resources
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxTokenLength longer tokens are truncated
+const maxTokenLength = 64
+
+// token a term and its byte range in the source text
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize split text into terms. Letters and digits are normalized by
+// foldRune, runs of them make terms, except that runs of CJK characters
+// are split into overlapping bigrams since they are written without spaces.
+func tokenize(text string) []token {
+	var (
+		tokens []token
+		word   strings.Builder
+		start  = -1
+		end    int
+		cjk    []cjkRune
+	)
+	flushWord := func() {
+		if start != -1 {
+			tokens = append(tokens, token{
+				term:  truncateTerm(word.String()),
+				start: start,
+				end:   end,
+			})
+			word.Reset()
+			start = -1
+		}
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			tokens = append(tokens, token{
+				term: cjk[0].folded, start: cjk[0].start, end: cjk[0].end})
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, token{
+				term:  cjk[i].folded + cjk[i+1].folded,
+				start: cjk[i].start,
+				end:   cjk[i+1].end,
+			})
+		}
+		cjk = cjk[:0]
+	}
+
+	for i, r := range text {
+		size := utf8.RuneLen(r)
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// combining marks, such as accents of decomposed letters
+			if start != -1 {
+				end = i + size
+			}
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, cjkRune{foldRune(r), i, i + size})
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			if start == -1 {
+				start = i
+			}
+			word.WriteString(foldRune(r))
+			end = i + size
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+type cjkRune struct {
+	folded     string
+	start, end int
+}
+
+// foldRune normalize a letter or digit for matching: compatibility forms
+// are replaced (NFKC), case is folded and accents are removed
+func foldRune(r rune) string {
+	if r < utf8.RuneSelf {
+		if 'A' <= r && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		return string(r)
+	}
+	if f, ok := foldTable[r]; ok {
+		return f
+	}
+	return string(unicode.ToLower(r))
+}
+
+// isCJK report whether r is a Chinese, Japanese or Korean character
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+func truncateTerm(term string) string {
+	if len(term) <= maxTokenLength {
+		return term
+	}
+	n := maxTokenLength
+	for n > 0 && !utf8.RuneStart(term[n]) {
+		n--
+	}
+	return term[:n]
+}
+
+// terms return terms of text
+func terms(text string) []string {
+	tokens := tokenize(text)
+	out := make([]string, len(tokens))
+	for i, t := range tokens {
+		out[i] = t.term
+	}
+	return out
+}
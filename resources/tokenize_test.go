@@ -0,0 +1,46 @@
+package resources
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{}},
+		{"Hello, World!", []string{"hello", "world"}},
+		{"Wörld CAFÉ", []string{"world", "cafe"}},
+		// decomposed accents
+		{"Café", []string{"cafe"}},
+		{"ﬁle ＡＢＣ１", []string{"file", "abc1"}},
+		{"中", []string{"中"}},
+		{"中文字", []string{"中文", "文字"}},
+		{"go中文", []string{"go", "中文"}},
+		{"a_b-c", []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		if got := terms(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("terms(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTokenizeRanges(t *testing.T) {
+	text := "Ünï 中文!"
+	var got []string
+	for _, tok := range tokenize(text) {
+		got = append(got, tok.term+"="+text[tok.start:tok.end])
+	}
+	if want := []string{"uni=Ünï", "中文=中文"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tokens = %q, want %q", got, want)
+	}
+
+	long := strings.Repeat("é", maxTokenLength)
+	if ts := terms(long); len(ts) != 1 || len(ts[0]) > maxTokenLength {
+		t.Errorf("terms of a long word = %q", ts)
+	}
+}
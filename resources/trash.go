@@ -0,0 +1,337 @@
+package resources
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// trashCollectionName names the collection of deleted subtrees, each entry
+// is keyed by id of the top article of the subtree, and holds the articles
+// and their children index entries
+var trashCollectionName = []byte("Trash")
+
+// trash entry field names
+var (
+	fDeletedAt = []byte("DeletedAt")
+	fArticles  = []byte("Articles")
+	fChildren  = []byte("Children")
+)
+
+// ErrTrashItemNotFound returned when a trash item does not exist
+var ErrTrashItemNotFound = errors.New("trash item not found")
+
+// TrashItem a deleted subtree
+type TrashItem struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Parent    string    `json:"parent"`
+	DeletedAt time.Time `json:"deletedAt"`
+	Count     int       `json:"count"`
+	// Version version of the top article
+	Version uint64 `json:"version"`
+}
+
+// MoveToTrash move article and all its descendants into trash
+func (a *Article) MoveToTrash() error {
+	if a.ID == RootArticleID {
+		return errors.New("unable to delete root article")
+	}
+
+	return db.Update(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		b := c.Bucket([]byte(a.ID))
+		if b == nil {
+			return ErrArticleNotFound
+		}
+
+		t, err := tx.CreateBucketIfNotExists(trashCollectionName)
+		if err != nil {
+			return err
+		}
+		if t.Bucket([]byte(a.ID)) != nil {
+			if err = t.DeleteBucket([]byte(a.ID)); err != nil {
+				return err
+			}
+		}
+		item, err := t.CreateBucket([]byte(a.ID))
+		if err != nil {
+			return err
+		}
+		if err = item.Put(fDeletedAt, formatTime(time.Now())); err != nil {
+			return err
+		}
+		articles, err := item.CreateBucket(fArticles)
+		if err != nil {
+			return err
+		}
+		children, err := item.CreateBucket(fChildren)
+		if err != nil {
+			return err
+		}
+
+		// detach subtree from its parent
+		if err = removeChild(tx, string(b.Get(fParent)), a.ID); err != nil {
+			return err
+		}
+
+		// move articles of subtree
+		ids := []string{a.ID}
+		for len(ids) > 0 {
+			id := ids[0]
+			ids = ids[1:]
+
+			subs := childrenOf(tx, id)
+			if len(subs) > 0 {
+				x, err := children.CreateBucket([]byte(id))
+				if err != nil {
+					return err
+				}
+				err = copyBucket(x, tx.Bucket(childrenCollectionName).Bucket([]byte(id)))
+				if err != nil {
+					return err
+				}
+				err = tx.Bucket(childrenCollectionName).DeleteBucket([]byte(id))
+				if err != nil {
+					return err
+				}
+				ids = append(ids, subs...)
+			}
+
+			x, err := articles.CreateBucket([]byte(id))
+			if err != nil {
+				return err
+			}
+			if err = copyBucket(x, c.Bucket([]byte(id))); err != nil {
+				return err
+			}
+			if err = unindexArticle(tx, []byte(id), x); err != nil {
+				return err
+			}
+			if err = c.DeleteBucket([]byte(id)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ListTrash list deleted subtrees, the latest deleted first. Those which
+// are private or were under a private article locked for session are left
+// out.
+func ListTrash(session string) (items []*TrashItem, err error) {
+	err = db.View(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		t := tx.Bucket(trashCollectionName)
+		if t == nil {
+			return nil
+		}
+		pv := newPrivacy(c, session)
+		return t.ForEach(func(k, _ []byte) error {
+			if !trashHidden(t, k, pv) {
+				items = append(items, trashItem(k, t.Bucket(k)))
+			}
+			return nil
+		})
+	})
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return
+}
+
+// GetTrashItem get a deleted subtree by id of its top article, unless it
+// is hidden from session, see ListTrash
+func GetTrashItem(session, id string) (item *TrashItem, err error) {
+	err = db.View(func(tx Tx) error {
+		t, err := visibleTrash(tx, session, id)
+		if err != nil {
+			return err
+		}
+		item = trashItem([]byte(id), t.Bucket([]byte(id)))
+		return nil
+	})
+	return
+}
+
+// visibleTrash return trash collection holding item id, if it is not
+// hidden from session
+func visibleTrash(tx Tx, session, id string) (Bucket, error) {
+	c, err := articleCollection(tx)
+	if err != nil {
+		return nil, err
+	}
+	t := tx.Bucket(trashCollectionName)
+	if t == nil || t.Bucket([]byte(id)) == nil ||
+		trashHidden(t, []byte(id), newPrivacy(c, session)) {
+		return nil, ErrTrashItemNotFound
+	}
+	return t, nil
+}
+
+// trashHidden tell whether trash item k of t is private or was under a
+// private article, locked for the session of pv
+func trashHidden(t Bucket, k []byte, pv *privacy) bool {
+	top := t.Bucket(k).Bucket(fArticles).Bucket(k)
+	if top.Get(fPrivate) != nil && !isUnlocked(pv.session, string(k)) {
+		return true
+	}
+	return pv.hidden(string(top.Get(fParent)))
+}
+
+// RestoreFromTrash put a deleted subtree back, under its original parent
+// if parent is empty
+func RestoreFromTrash(id, parent string) error {
+	return db.Update(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		t := tx.Bucket(trashCollectionName)
+		if t == nil || t.Bucket([]byte(id)) == nil {
+			return ErrTrashItemNotFound
+		}
+		item := t.Bucket([]byte(id))
+		articles := item.Bucket(fArticles)
+
+		if parent == "" {
+			parent = string(articles.Bucket([]byte(id)).Get(fParent))
+		}
+		if c.Bucket([]byte(parent)) == nil {
+			return errors.New("parent article not exists, specify another one")
+		}
+
+		// put articles back
+		err = articles.ForEach(func(k, _ []byte) error {
+			if c.Bucket(k) != nil {
+				return errors.New("article already exists")
+			}
+			b, err := c.CreateBucket(k)
+			if err != nil {
+				return err
+			}
+			if err = copyBucket(b, articles.Bucket(k)); err != nil {
+				return err
+			}
+			return indexArticle(tx, k, b)
+		})
+		if err != nil {
+			return err
+		}
+		if err = saveRevision(tx, id, c.Bucket([]byte(id))); err != nil {
+			return err
+		}
+		if err = c.Bucket([]byte(id)).Put(fParent, []byte(parent)); err != nil {
+			return err
+		}
+		if _, err = bumpVersion(c.Bucket([]byte(id))); err != nil {
+			return err
+		}
+
+		// restore children index
+		children := item.Bucket(fChildren)
+		err = children.ForEach(func(k, _ []byte) error {
+			return setChildren(tx, string(k), orderedChildren(children.Bucket(k)))
+		})
+		if err != nil {
+			return err
+		}
+		if err = addChild(tx, parent, id); err != nil {
+			return err
+		}
+
+		return t.DeleteBucket([]byte(id))
+	})
+}
+
+// PurgeTrash delete a trash item permanently, unless it is hidden from
+// session
+func PurgeTrash(session, id string) error {
+	return db.Update(func(tx Tx) error {
+		t, err := visibleTrash(tx, session, id)
+		if err != nil {
+			return err
+		}
+		return purgeTrashItem(tx, t, []byte(id))
+	})
+}
+
+// PurgeTrashBefore delete trash items which were deleted before deadline,
+// except those hidden from session
+func PurgeTrashBefore(session string, deadline time.Time) error {
+	return purgeTrashBefore(deadline, session, false)
+}
+
+// ExpireTrash delete all trash items which were deleted before deadline
+func ExpireTrash(deadline time.Time) error {
+	return purgeTrashBefore(deadline, "", true)
+}
+
+// purgeTrashBefore delete trash items which were deleted before deadline,
+// those hidden from session are kept unless hidden is true
+func purgeTrashBefore(deadline time.Time, session string, hidden bool) error {
+	return db.Update(func(tx Tx) error {
+		c, err := articleCollection(tx)
+		if err != nil {
+			return err
+		}
+		t := tx.Bucket(trashCollectionName)
+		if t == nil {
+			return nil
+		}
+		pv := newPrivacy(c, session)
+
+		var expired [][]byte
+		t.ForEach(func(k, _ []byte) error {
+			if parseTime(t.Bucket(k).Get(fDeletedAt)).Before(deadline) &&
+				(hidden || !trashHidden(t, k, pv)) {
+				expired = append(expired, append([]byte{}, k...))
+			}
+			return nil
+		})
+		for _, k := range expired {
+			if err := purgeTrashItem(tx, t, k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func purgeTrashItem(tx Tx, t Bucket, id []byte) error {
+	err := t.Bucket(id).Bucket(fArticles).ForEach(func(k, _ []byte) error {
+		if err := deleteHistory(tx, string(k)); err != nil {
+			return err
+		}
+		return deleteAttachments(tx, string(k))
+	})
+	if err != nil {
+		return err
+	}
+	return t.DeleteBucket(id)
+}
+
+func trashItem(id []byte, item Bucket) *TrashItem {
+	articles := item.Bucket(fArticles)
+	top := articles.Bucket(id)
+	i := &TrashItem{
+		ID:        string(id),
+		Title:     string(top.Get(fTitle)),
+		Parent:    string(top.Get(fParent)),
+		DeletedAt: parseTime(item.Get(fDeletedAt)),
+		Version:   decodeVersion(top.Get(fVersion)),
+	}
+	articles.ForEach(func(_, _ []byte) error {
+		i.Count++
+		return nil
+	})
+	return i
+}
@@ -0,0 +1,62 @@
+package resources
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	openTestDatabase(t)
+	a := createArticle(t, RootArticleID, "a")
+	b := createArticle(t, RootArticleID, "b")
+	sub := createArticle(t, a.ID, "sub")
+
+	if err := a.MoveToTrash(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetArticle(sub.ID); err != ErrArticleNotFound {
+		t.Errorf("sub-article in trash: %v", err)
+	}
+	items, err := ListTrash("")
+	if err != nil || len(items) != 1 || items[0].Count != 2 || items[0].Title != "a" {
+		t.Fatalf("ListTrash = %+v, %v", items, err)
+	}
+
+	// restored elsewhere, with its sub-articles
+	if err := RestoreFromTrash(a.ID, b.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := subTitles(t, b.ID); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("sub-articles of b = %v", got)
+	}
+	if got := subTitles(t, a.ID); !reflect.DeepEqual(got, []string{"sub"}) {
+		t.Errorf("sub-articles of restored a = %v", got)
+	}
+	if items, _ := ListTrash(""); len(items) != 0 {
+		t.Errorf("trash after restore = %+v", items)
+	}
+	if err := RestoreFromTrash(a.ID, ""); err != ErrTrashItemNotFound {
+		t.Errorf("restore again: %v", err)
+	}
+}
+
+func TestExpireTrash(t *testing.T) {
+	openTestDatabase(t)
+	a := createArticle(t, RootArticleID, "a")
+	if err := a.MoveToTrash(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExpireTrash(time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := ListTrash(""); len(items) != 1 {
+		t.Errorf("trash before expiry = %+v", items)
+	}
+	if err := ExpireTrash(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := ListTrash(""); len(items) != 0 {
+		t.Errorf("trash after expiry = %+v", items)
+	}
+}
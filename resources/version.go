@@ -0,0 +1,28 @@
+package resources
+
+import (
+	"encoding/binary"
+)
+
+// fVersion article field counting changes of the article, articles written
+// before versions were kept have version 0
+var fVersion = []byte("Version")
+
+// bumpVersion increase version of article b, returning the new version
+func bumpVersion(b Bucket) (uint64, error) {
+	v := decodeVersion(b.Get(fVersion)) + 1
+	return v, b.Put(fVersion, encodeVersion(v))
+}
+
+func encodeVersion(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func decodeVersion(b []byte) uint64 {
+	if len(b) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/simpleelegant/notes/api"
+	"github.com/simpleelegant/notes/resources"
 )
 
 func registerRoutes(assetsHandler http.Handler) {
@@ -12,17 +13,71 @@ func registerRoutes(assetsHandler http.Handler) {
 		http.RedirectHandler("/assets/", http.StatusMovedPermanently))
 	http.Handle("/assets/", assetsHandler)
 
-	http.HandleFunc("/articles/search", json(api.SearchArticles))
-	http.HandleFunc("/articles/get", json(api.GetArticle))
-	http.HandleFunc("/articles/create", post(json(api.CreateArticle)))
-	http.HandleFunc("/articles/update", post(json(api.UpdateArticle)))
-	http.HandleFunc("/articles/delete", post(json(api.DeleteArticle)))
+	// calls reading data of a notebook, which run together
+	handle := func(pattern string, h http.HandlerFunc) {
+		http.HandleFunc(pattern, inNotebook(h, false))
+	}
+	// calls changing data of a notebook, which run alone
+	change := func(pattern string, h http.HandlerFunc) {
+		http.HandleFunc(pattern, inNotebook(h, true))
+	}
+	handle("/articles/search", json(api.SearchArticles))
+	handle("/articles/switch", json(api.SwitchArticle))
+	handle("/articles/get", json(api.GetArticle))
+	change("/articles/create", post(json(api.CreateArticle)))
+	change("/articles/update", post(json(api.UpdateArticle)))
+	change("/articles/delete", post(json(api.DeleteArticle)))
+	change("/articles/move", post(json(api.MoveArticle)))
+	change("/articles/copy", post(json(api.CopyArticle)))
+	change("/articles/template", post(json(api.SetTemplate)))
+	change("/articles/private", post(json(api.SetPrivate)))
+	change("/articles/private/clear", post(json(api.ClearPrivate)))
+	handle("/articles/unlock", post(json(api.UnlockPrivate)))
+	handle("/articles/lock", post(json(api.LockPrivate)))
+	handle("/articles/revisions", json(api.ListRevisions))
+	handle("/articles/revisions/get", json(api.GetRevision))
+	handle("/articles/revisions/diff", json(api.DiffRevisions))
+	change("/articles/revisions/rollback",
+		post(json(api.RollbackArticle)))
+
+	handle("/trash", json(api.ListTrash))
+	change("/trash/restore", post(json(api.RestoreTrash)))
+	change("/trash/purge", post(json(api.PurgeTrash)))
+
+	handle("/tags", json(api.ListTags))
+	handle("/tags/articles", json(api.GetArticlesByTag))
+	change("/tags/rename", post(json(api.RenameTag)))
+
+	handle("/templates", json(api.ListTemplates))
+	handle("/properties/query", json(api.QueryProperties))
+
+	handle("/searches", json(api.ListSavedSearches))
+	change("/searches/create", post(json(api.CreateSavedSearch)))
+	change("/searches/update", post(json(api.UpdateSavedSearch)))
+	change("/searches/delete", post(json(api.DeleteSavedSearch)))
+
+	handle("/attachments", json(api.ListAttachments))
+	change("/attachments/upload", post(json(api.UploadAttachment)))
+	change("/attachments/delete", post(json(api.DeleteAttachment)))
+	handle("/attachments/get", api.DownloadAttachment)
 
-	http.HandleFunc("/diagram/render", json(api.RenderDiagram))
-	http.HandleFunc("/md5", json(api.MD5))
+	handle("/diagram/render", json(api.RenderDiagram))
+	handle("/md5", json(api.MD5))
 
-	http.HandleFunc("/restore", post(api.Restore))
-	http.HandleFunc("/export", post(api.Export))
+	change("/restore", post(api.Restore))
+	handle("/export", post(api.Export))
+
+	handle("/encryption", json(api.GetEncryption))
+	change("/encryption/enable", post(json(api.EnableEncryption)))
+	change("/unlock", post(json(api.Unlock)))
+
+	handle("/check", json(api.CheckDatabase))
+	change("/check/repair", post(json(api.RepairDatabase)))
+
+	http.HandleFunc("/notebooks", json(api.ListNotebooks))
+	http.HandleFunc("/notebooks/create", post(json(api.CreateNotebook)))
+	http.HandleFunc("/notebooks/rename", post(json(api.RenameNotebook)))
+	http.HandleFunc("/notebooks/switch", post(json(api.SwitchNotebook)))
 }
 
 type handler func(*http.Request) (int, interface{})
@@ -30,7 +85,26 @@ type handler func(*http.Request) (int, interface{})
 func json(h handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		status, body := h(r)
+		if v, ok := body.(api.Versioned); ok {
+			w.Header().Set("ETag", api.ETag(v.Version))
+			body = v.Body
+		}
+		if e, ok := body.(*resources.PrivateError); ok {
+			// in JSON, so that UI can ask for password of the private article
+			status, body = http.StatusForbidden, *e
+		}
+		if e, ok := body.(*api.ConflictError); ok {
+			// in JSON, so that UI can tell which version is current
+			w.Header().Set("ETag", api.ETag(e.Version))
+			status, body = http.StatusConflict, *e
+		}
 		if err, ok := body.(error); ok {
+			switch err {
+			case resources.ErrLocked:
+				status = http.StatusLocked
+			case api.ErrPreconditionRequired:
+				status = http.StatusPreconditionRequired
+			}
 			w.WriteHeader(status)
 			w.Write([]byte(err.Error()))
 			return
@@ -48,6 +122,30 @@ func json(h handler) http.HandlerFunc {
 	}
 }
 
+// inNotebook run h with the notebook named by parameter notebook in use,
+// or the current notebook if it is empty, alone if h changes it.
+// Clients are given a session.
+func inNotebook(h http.HandlerFunc, changes bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := api.StartSession(w, r); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		use := resources.UseNotebook
+		if changes {
+			use = resources.ChangeNotebook
+		}
+		err := use(r.FormValue("notebook"), func() {
+			h(w, r)
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+		}
+	}
+}
+
 func post(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {